@@ -11,6 +11,7 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Position() (line, column int) // position of the node's token in the source code
 }
 
 // StatementNode is an interface type for representing all statement nodes in the AST.
@@ -40,6 +41,14 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+// Position returns the position of the first statement the program holds.
+func (p *Program) Position() (line, column int) {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Position()
+	}
+	return 0, 0
+}
+
 // String will output the whole program's source code back as it is.
 // This makes testing the structure of the AST very simple and easy.
 func (p *Program) String() string {
@@ -59,9 +68,9 @@ func (p *Program) String() string {
 2. So a statement's node will contain the token that identifies that statement.
 */
 
-// LetStatementNode is a type for representing all "let" statements in AST. ex:= `let x = 5 * 6`
+// LetStatementNode is a type for representing all "let" and "const" statements in AST. ex:= `let x = 5 * 6`
 type LetStatementNode struct {
-	Token token.Token // token.LET
+	Token token.Token // token.LET or token.CONST
 	Iden  *IdentifierNode
 	Value ExpressionNode
 }
@@ -70,7 +79,11 @@ type LetStatementNode struct {
 func (ls *LetStatementNode) statementNode() {}
 
 // TokenLiteral returns the LetStatementNode's token literal.
-func (ls *LetStatementNode) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatementNode) TokenLiteral() string         { return ls.Token.Literal }
+func (ls *LetStatementNode) Position() (line, column int) { return ls.Token.Line, ls.Token.Column }
+
+// IsConst tells if the statement declares a constant, which cannot be bound again in the same scope.
+func (ls *LetStatementNode) IsConst() bool { return ls.Token.Type == token.CONST.Type }
 
 func (ls *LetStatementNode) String() string {
 	var out bytes.Buffer
@@ -95,7 +108,8 @@ type IdentifierNode struct {
 func (i *IdentifierNode) expressionNode() {}
 
 // TokenLiteral returns the IdentifierNode's token literal.
-func (i *IdentifierNode) TokenLiteral() string { return i.Token.Literal }
+func (i *IdentifierNode) TokenLiteral() string         { return i.Token.Literal }
+func (i *IdentifierNode) Position() (line, column int) { return i.Token.Line, i.Token.Column }
 
 func (i *IdentifierNode) String() string { return i.Name }
 
@@ -109,7 +123,8 @@ type ReturnStatementNode struct {
 func (rs *ReturnStatementNode) statementNode() {}
 
 // TokenLiteral returns the ReturnStatementNode's token literal.
-func (rs *ReturnStatementNode) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatementNode) TokenLiteral() string         { return rs.Token.Literal }
+func (rs *ReturnStatementNode) Position() (line, column int) { return rs.Token.Line, rs.Token.Column }
 
 func (rs *ReturnStatementNode) String() string {
 	var out bytes.Buffer
@@ -132,6 +147,9 @@ func (es *ExpressionStatementNode) statementNode() {}
 
 // TokenLiteral returns the ExpressionStatementNode's token literal.
 func (es *ExpressionStatementNode) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatementNode) Position() (line, column int) {
+	return es.Token.Line, es.Token.Column
+}
 
 func (es *ExpressionStatementNode) String() string {
 	var out bytes.Buffer
@@ -147,9 +165,10 @@ type IntegerLiteralNode struct {
 }
 
 // TokenLiteral returns the IntegerLiteralNode's token literal.
-func (il *IntegerLiteralNode) TokenLiteral() string { return il.Token.Literal }
-func (il *IntegerLiteralNode) expressionNode()      {}
-func (il *IntegerLiteralNode) String() string       { return il.Token.Literal }
+func (il *IntegerLiteralNode) TokenLiteral() string         { return il.Token.Literal }
+func (il *IntegerLiteralNode) Position() (line, column int) { return il.Token.Line, il.Token.Column }
+func (il *IntegerLiteralNode) expressionNode()              {}
+func (il *IntegerLiteralNode) String() string               { return il.Token.Literal }
 
 // PrefixExpressionNode is a type for representing all "prefix" expressions in AST.
 type PrefixExpressionNode struct {
@@ -159,8 +178,9 @@ type PrefixExpressionNode struct {
 }
 
 // TokenLiteral returns the PrefixExpressionNode's token literal.
-func (pe *PrefixExpressionNode) TokenLiteral() string { return pe.Token.Literal }
-func (pe *PrefixExpressionNode) expressionNode()      {}
+func (pe *PrefixExpressionNode) TokenLiteral() string         { return pe.Token.Literal }
+func (pe *PrefixExpressionNode) Position() (line, column int) { return pe.Token.Line, pe.Token.Column }
+func (pe *PrefixExpressionNode) expressionNode()              {}
 func (pe *PrefixExpressionNode) String() string {
 	var out bytes.Buffer
 
@@ -182,8 +202,9 @@ type InfixExpressionNode struct {
 }
 
 // TokenLiteral returns the InfixExpressionNode's token literal.
-func (ie *InfixExpressionNode) TokenLiteral() string { return ie.Token.Literal }
-func (ie *InfixExpressionNode) expressionNode()      {}
+func (ie *InfixExpressionNode) TokenLiteral() string         { return ie.Token.Literal }
+func (ie *InfixExpressionNode) Position() (line, column int) { return ie.Token.Line, ie.Token.Column }
+func (ie *InfixExpressionNode) expressionNode()              {}
 func (ie *InfixExpressionNode) String() string {
 	var out bytes.Buffer
 
@@ -204,9 +225,10 @@ type BooleanNode struct {
 }
 
 // TokenLiteral returns the BooleanNode's token literal.
-func (b *BooleanNode) TokenLiteral() string { return b.Token.Literal }
-func (b *BooleanNode) String() string       { return b.Token.Literal }
-func (b *BooleanNode) expressionNode()      {}
+func (b *BooleanNode) TokenLiteral() string         { return b.Token.Literal }
+func (b *BooleanNode) Position() (line, column int) { return b.Token.Line, b.Token.Column }
+func (b *BooleanNode) String() string               { return b.Token.Literal }
+func (b *BooleanNode) expressionNode()              {}
 
 // IfExpressionNode is a type for representing all "if" expressions in AST.
 type IfExpressionNode struct {
@@ -217,8 +239,9 @@ type IfExpressionNode struct {
 }
 
 // TokenLiteral returns the IfExpressionNode's token literal.
-func (ie *IfExpressionNode) TokenLiteral() string { return ie.Token.Literal }
-func (ie *IfExpressionNode) expressionNode()      {}
+func (ie *IfExpressionNode) TokenLiteral() string         { return ie.Token.Literal }
+func (ie *IfExpressionNode) Position() (line, column int) { return ie.Token.Line, ie.Token.Column }
+func (ie *IfExpressionNode) expressionNode()              {}
 func (ie *IfExpressionNode) String() string {
 	var out bytes.Buffer
 
@@ -239,11 +262,13 @@ func (ie *IfExpressionNode) String() string {
 type BlockStatementNode struct {
 	Token      token.Token // The { token
 	Statements []StatementNode
+	EndToken   token.Token // The } token
 }
 
 // TokenLiteral returns the BlockStatementNode's token literal.
-func (bs *BlockStatementNode) TokenLiteral() string { return bs.Token.Literal }
-func (bs *BlockStatementNode) statementNode()       {}
+func (bs *BlockStatementNode) TokenLiteral() string         { return bs.Token.Literal }
+func (bs *BlockStatementNode) Position() (line, column int) { return bs.Token.Line, bs.Token.Column }
+func (bs *BlockStatementNode) statementNode()               {}
 func (bs *BlockStatementNode) String() string {
 	var out bytes.Buffer
 	out.WriteString("{")
@@ -263,8 +288,9 @@ type FunctionLiteralNode struct {
 }
 
 // TokenLiteral returns the FunctionLiteralNode's token literal.
-func (fl *FunctionLiteralNode) TokenLiteral() string { return fl.Token.Literal }
-func (fl *FunctionLiteralNode) expressionNode()      {}
+func (fl *FunctionLiteralNode) TokenLiteral() string         { return fl.Token.Literal }
+func (fl *FunctionLiteralNode) Position() (line, column int) { return fl.Token.Line, fl.Token.Column }
+func (fl *FunctionLiteralNode) expressionNode()              {}
 func (fl *FunctionLiteralNode) String() string {
 	var out bytes.Buffer
 
@@ -287,11 +313,13 @@ type CallExpressionNode struct {
 	Token     token.Token    // The left paren "(" token
 	Function  ExpressionNode // either IdentifierNode or FunctionLiteralNode
 	Arguments []ExpressionNode
+	EndToken  token.Token // the right paren ")" token
 }
 
 // TokenLiteral returns the CallExpressionNode's token literal.
-func (ce *CallExpressionNode) TokenLiteral() string { return ce.Token.Literal }
-func (ce *CallExpressionNode) expressionNode()      {}
+func (ce *CallExpressionNode) TokenLiteral() string         { return ce.Token.Literal }
+func (ce *CallExpressionNode) Position() (line, column int) { return ce.Token.Line, ce.Token.Column }
+func (ce *CallExpressionNode) expressionNode()              {}
 func (ce *CallExpressionNode) String() string {
 	var out bytes.Buffer
 
@@ -315,6 +343,421 @@ type StringLiteralNode struct {
 }
 
 // TokenLiteral returns the StringLiteralNode's token literal.
-func (sn *StringLiteralNode) TokenLiteral() string { return sn.Token.Literal }
-func (sn *StringLiteralNode) expressionNode()      {}
-func (sn *StringLiteralNode) String() string       { return sn.Token.Literal }
+func (sn *StringLiteralNode) TokenLiteral() string         { return sn.Token.Literal }
+func (sn *StringLiteralNode) Position() (line, column int) { return sn.Token.Line, sn.Token.Column }
+func (sn *StringLiteralNode) expressionNode()              {}
+func (sn *StringLiteralNode) String() string               { return `"` + sn.Value + `"` }
+
+// ImportStatementNode is a type for representing all "import" statements in AST. ex:- import "path/to/lib.yz" as lib
+type ImportStatementNode struct {
+	Token token.Token // token.IMPORT
+	Path  *StringLiteralNode
+	Alias *IdentifierNode // nil when the import has no "as" clause
+}
+
+// *ImportStatementNode implements StatementNode interface.
+func (is *ImportStatementNode) statementNode() {}
+
+// TokenLiteral returns the ImportStatementNode's token literal.
+func (is *ImportStatementNode) TokenLiteral() string         { return is.Token.Literal }
+func (is *ImportStatementNode) Position() (line, column int) { return is.Token.Line, is.Token.Column }
+
+func (is *ImportStatementNode) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(`"` + is.Path.Value + `"`)
+	if is.Alias != nil {
+		out.WriteString(" as ")
+		out.WriteString(is.Alias.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// ExportStatementNode is a type for representing all "export" statements in AST. ex:- export let x = 5, export const y = 6
+type ExportStatementNode struct {
+	Token     token.Token // token.EXPORT
+	Statement *LetStatementNode
+}
+
+// *ExportStatementNode implements StatementNode interface.
+func (es *ExportStatementNode) statementNode() {}
+
+// TokenLiteral returns the ExportStatementNode's token literal.
+func (es *ExportStatementNode) TokenLiteral() string         { return es.Token.Literal }
+func (es *ExportStatementNode) Position() (line, column int) { return es.Token.Line, es.Token.Column }
+
+func (es *ExportStatementNode) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
+// MemberExpressionNode is a type for representing all "member access" expressions in AST. ex:- lib.name
+type MemberExpressionNode struct {
+	Token    token.Token // The "." token
+	Object   ExpressionNode
+	Property *IdentifierNode
+}
+
+// TokenLiteral returns the MemberExpressionNode's token literal.
+func (me *MemberExpressionNode) TokenLiteral() string         { return me.Token.Literal }
+func (me *MemberExpressionNode) Position() (line, column int) { return me.Token.Line, me.Token.Column }
+func (me *MemberExpressionNode) expressionNode()              {}
+func (me *MemberExpressionNode) String() string {
+	return me.Object.String() + "." + me.Property.String()
+}
+
+// ArrayLiteralNode is a type for representing all "array" literal expressions in AST. ex:- [1, 2 * 3, "foo"]
+type ArrayLiteralNode struct {
+	Token    token.Token // the "[" token
+	Elements []ExpressionNode
+	EndToken token.Token // the "]" token
+}
+
+// TokenLiteral returns the ArrayLiteralNode's token literal.
+func (al *ArrayLiteralNode) TokenLiteral() string         { return al.Token.Literal }
+func (al *ArrayLiteralNode) Position() (line, column int) { return al.Token.Line, al.Token.Column }
+func (al *ArrayLiteralNode) expressionNode()              {}
+func (al *ArrayLiteralNode) String() string {
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashLiteralNode is a type for representing all "hash" literal expressions in AST. ex:- {"one": 1, 2: "two"}
+type HashLiteralNode struct {
+	Token    token.Token // the "{" token
+	Keys     []ExpressionNode
+	Values   []ExpressionNode // the values of the Keys, in the same order
+	EndToken token.Token      // the "}" token
+}
+
+// TokenLiteral returns the HashLiteralNode's token literal.
+func (hl *HashLiteralNode) TokenLiteral() string         { return hl.Token.Literal }
+func (hl *HashLiteralNode) Position() (line, column int) { return hl.Token.Line, hl.Token.Column }
+func (hl *HashLiteralNode) expressionNode()              {}
+func (hl *HashLiteralNode) String() string {
+	pairs := []string{}
+	for idx, key := range hl.Keys {
+		pairs = append(pairs, key.String()+": "+hl.Values[idx].String())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// IndexExpressionNode is a type for representing all "index" expressions in AST. ex:- myArray[1]
+type IndexExpressionNode struct {
+	Token    token.Token // the "[" token
+	Left     ExpressionNode
+	Index    ExpressionNode
+	EndToken token.Token // the "]" token
+}
+
+// TokenLiteral returns the IndexExpressionNode's token literal.
+func (ie *IndexExpressionNode) TokenLiteral() string         { return ie.Token.Literal }
+func (ie *IndexExpressionNode) Position() (line, column int) { return ie.Token.Line, ie.Token.Column }
+func (ie *IndexExpressionNode) expressionNode()              {}
+func (ie *IndexExpressionNode) String() string {
+	return "(" + ie.Left.String() + "[" + ie.Index.String() + "])"
+}
+
+// ThrowStatementNode is a type for representing all "throw" statements in AST. ex:- throw "bad input"
+type ThrowStatementNode struct {
+	Token token.Token // token.THROW
+	Value ExpressionNode
+}
+
+// *ThrowStatementNode implements StatementNode interface.
+func (ts *ThrowStatementNode) statementNode() {}
+
+// TokenLiteral returns the ThrowStatementNode's token literal.
+func (ts *ThrowStatementNode) TokenLiteral() string         { return ts.Token.Literal }
+func (ts *ThrowStatementNode) Position() (line, column int) { return ts.Token.Line, ts.Token.Column }
+func (ts *ThrowStatementNode) String() string {
+	return ts.TokenLiteral() + " " + ts.Value.String() + ";"
+}
+
+// TryExpressionNode is a type for representing all "try" expressions in AST.
+// ex:- try { <block> } catch (e) { <block> } finally { <block> }, either the catch or the finally block can be left out.
+type TryExpressionNode struct {
+	Token      token.Token // the "try" token
+	Block      *BlockStatementNode
+	CatchParam *IdentifierNode // nil when the catch clause has no parameter
+	Catch      *BlockStatementNode
+	Finally    *BlockStatementNode
+}
+
+// TokenLiteral returns the TryExpressionNode's token literal.
+func (te *TryExpressionNode) TokenLiteral() string         { return te.Token.Literal }
+func (te *TryExpressionNode) Position() (line, column int) { return te.Token.Line, te.Token.Column }
+func (te *TryExpressionNode) expressionNode()              {}
+func (te *TryExpressionNode) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Block.String())
+
+	if te.Catch != nil {
+		out.WriteString(" catch ")
+		if te.CatchParam != nil {
+			out.WriteString("(" + te.CatchParam.String() + ") ")
+		}
+		out.WriteString(te.Catch.String())
+	}
+
+	if te.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(te.Finally.String())
+	}
+
+	return out.String()
+}
+
+// PropagateExpressionNode is a type for representing all "?" expressions in AST. ex:- parse(input)?
+// If the expression evaluates to an error value, the function the expression is in returns that error value.
+type PropagateExpressionNode struct {
+	Token token.Token // the "?" token
+	Value ExpressionNode
+}
+
+// TokenLiteral returns the PropagateExpressionNode's token literal.
+func (pe *PropagateExpressionNode) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PropagateExpressionNode) Position() (line, column int) {
+	return pe.Token.Line, pe.Token.Column
+}
+func (pe *PropagateExpressionNode) expressionNode() {}
+func (pe *PropagateExpressionNode) String() string  { return "(" + pe.Value.String() + "?)" }
+
+// SpawnExpressionNode is a type for representing all "spawn" expressions in AST. ex:- spawn worker(jobs, 1)
+// The call is evaluated on a new goroutine, the expression evaluates to a channel that receives what the call returns.
+type SpawnExpressionNode struct {
+	Token token.Token // the "spawn" token
+	Call  *CallExpressionNode
+}
+
+// TokenLiteral returns the SpawnExpressionNode's token literal.
+func (se *SpawnExpressionNode) TokenLiteral() string         { return se.Token.Literal }
+func (se *SpawnExpressionNode) Position() (line, column int) { return se.Token.Line, se.Token.Column }
+func (se *SpawnExpressionNode) expressionNode()              {}
+func (se *SpawnExpressionNode) String() string               { return "(spawn " + se.Call.String() + ")" }
+
+// SelectExpressionNode is a type for representing all "select" expressions in AST.
+// ex:- select { case v = receive(results) { v } case send(jobs, 1) { 0 } default { -1 } }, the default case can be left out.
+type SelectExpressionNode struct {
+	Token    token.Token // the "select" token
+	Cases    []*SelectCaseNode
+	Default  *BlockStatementNode // nil when the select has no default case
+	EndToken token.Token         // the "}" token that closes the select
+}
+
+// TokenLiteral returns the SelectExpressionNode's token literal.
+func (se *SelectExpressionNode) TokenLiteral() string         { return se.Token.Literal }
+func (se *SelectExpressionNode) Position() (line, column int) { return se.Token.Line, se.Token.Column }
+func (se *SelectExpressionNode) expressionNode()              {}
+func (se *SelectExpressionNode) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("select {")
+	for _, c := range se.Cases {
+		out.WriteString(c.String() + " ")
+	}
+	if se.Default != nil {
+		out.WriteString("default " + se.Default.String())
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// SelectCaseNode is a type for representing the cases of select expressions in AST.
+// ex:- case v = receive(results) { <block> }, or case send(jobs, 1) { <block> }
+type SelectCaseNode struct {
+	Token     token.Token         // the "case" token
+	Name      *IdentifierNode     // the name a received value is bound to in the block, nil when there is none
+	Operation *CallExpressionNode // a receive(channel) or send(channel, value) call
+	Body      *BlockStatementNode
+}
+
+// TokenLiteral returns the SelectCaseNode's token literal.
+func (sc *SelectCaseNode) TokenLiteral() string         { return sc.Token.Literal }
+func (sc *SelectCaseNode) Position() (line, column int) { return sc.Token.Line, sc.Token.Column }
+func (sc *SelectCaseNode) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("case ")
+	if sc.Name != nil {
+		out.WriteString(sc.Name.String() + " = ")
+	}
+	out.WriteString(sc.Operation.String() + " ")
+	out.WriteString(sc.Body.String())
+
+	return out.String()
+}
+
+// IsSend tells whether the case sends a value, instead of receiving one.
+func (sc *SelectCaseNode) IsSend() bool {
+	return sc.Operation.Function.(*IdentifierNode).Name == "send"
+}
+
+// StructStatementNode is a type for representing all "struct" declarations in AST. ex:- struct Point { x, y }
+type StructStatementNode struct {
+	Token    token.Token // the "struct" token
+	Name     *IdentifierNode
+	Fields   []*IdentifierNode
+	EndToken token.Token // the "}" token that closes the fields
+}
+
+// *StructStatementNode implements StatementNode interface.
+func (ss *StructStatementNode) statementNode() {}
+
+// TokenLiteral returns the StructStatementNode's token literal.
+func (ss *StructStatementNode) TokenLiteral() string         { return ss.Token.Literal }
+func (ss *StructStatementNode) Position() (line, column int) { return ss.Token.Line, ss.Token.Column }
+func (ss *StructStatementNode) String() string {
+	fields := []string{}
+	for _, field := range ss.Fields {
+		fields = append(fields, field.String())
+	}
+
+	return ss.TokenLiteral() + " " + ss.Name.String() + " {" + strings.Join(fields, ", ") + "};"
+}
+
+// StructLiteralNode is a type for representing all "struct" literal expressions in AST. ex:- Point{x: 1, y: 2}
+type StructLiteralNode struct {
+	Token    token.Token // the "{" token, the literal's position is the position of its Type
+	Type     *IdentifierNode
+	Fields   []*IdentifierNode // the names of the fields, in the order they are given
+	Values   []ExpressionNode  // the values of the Fields
+	EndToken token.Token       // the "}" token
+}
+
+// TokenLiteral returns the StructLiteralNode's token literal.
+func (sl *StructLiteralNode) TokenLiteral() string         { return sl.Token.Literal }
+func (sl *StructLiteralNode) Position() (line, column int) { return sl.Type.Position() }
+func (sl *StructLiteralNode) expressionNode()              {}
+func (sl *StructLiteralNode) String() string {
+	fields := []string{}
+	for idx, field := range sl.Fields {
+		fields = append(fields, field.String()+": "+sl.Values[idx].String())
+	}
+
+	return sl.Type.String() + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// AssignStatementNode is a type for representing all statements that assign a value to a field in AST. ex:- p.x = 3
+type AssignStatementNode struct {
+	Token  token.Token // the first token of the statement, like the Token of an ExpressionStatementNode
+	Target *MemberExpressionNode
+	Value  ExpressionNode
+}
+
+// *AssignStatementNode implements StatementNode interface.
+func (as *AssignStatementNode) statementNode() {}
+
+// TokenLiteral returns the AssignStatementNode's token literal.
+func (as *AssignStatementNode) TokenLiteral() string         { return as.Token.Literal }
+func (as *AssignStatementNode) Position() (line, column int) { return as.Token.Line, as.Token.Column }
+func (as *AssignStatementNode) String() string {
+	return as.Target.String() + " = " + as.Value.String() + ";"
+}
+
+// MatchExpressionNode is a type for representing all "match" expressions in AST.
+// ex:- match (value) { 1 => "one", [a, b] => a + b, {"k": v} => v, _ => { 0 } }
+type MatchExpressionNode struct {
+	Token    token.Token // the "match" token
+	Value    ExpressionNode
+	Arms     []*MatchArmNode
+	EndToken token.Token // the "}" token that closes the arms
+}
+
+// TokenLiteral returns the MatchExpressionNode's token literal.
+func (me *MatchExpressionNode) TokenLiteral() string         { return me.Token.Literal }
+func (me *MatchExpressionNode) Position() (line, column int) { return me.Token.Line, me.Token.Column }
+func (me *MatchExpressionNode) expressionNode()              {}
+func (me *MatchExpressionNode) String() string {
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	return "match (" + me.Value.String() + ") {" + strings.Join(arms, ", ") + "}"
+}
+
+// MatchArmNode is a type for representing the arms of match expressions in AST. ex:- [a, b] => a + b, or _ => { 0 }
+// The pattern is made of integer, string and boolean literals, where integers can be negative, identifiers, which
+// match anything and bind it to their name unless the name is _, and array literals, struct literals and hash patterns
+// of patterns.
+type MatchArmNode struct {
+	Token   token.Token // the first token of the pattern
+	Pattern ExpressionNode
+	Value   ExpressionNode      // the expression the arm evaluates to, nil when the arm has a Body
+	Body    *BlockStatementNode // the block the arm evaluates to, nil when the arm has a Value
+}
+
+// TokenLiteral returns the MatchArmNode's token literal.
+func (ma *MatchArmNode) TokenLiteral() string         { return ma.Token.Literal }
+func (ma *MatchArmNode) Position() (line, column int) { return ma.Token.Line, ma.Token.Column }
+func (ma *MatchArmNode) String() string {
+	if ma.Body != nil {
+		return ma.Pattern.String() + " => " + ma.Body.String()
+	}
+	if _, ok := ma.Value.(*HashLiteralNode); ok { // Without the parens, the hash would be parsed as the body of the arm.
+		return ma.Pattern.String() + " => (" + ma.Value.String() + ")"
+	}
+	return ma.Pattern.String() + " => " + ma.Value.String()
+}
+
+// Bindings returns the identifiers of the pattern that bind the parts of the value they match, in the order they are
+// in the pattern. The types and the field names of struct literals, and _, do not bind anything.
+func (ma *MatchArmNode) Bindings() []*IdentifierNode {
+	var bindings []*IdentifierNode
+	var collect func(pattern ExpressionNode)
+	collect = func(pattern ExpressionNode) {
+		switch pattern := pattern.(type) {
+		case *IdentifierNode:
+			if pattern.Name != "_" {
+				bindings = append(bindings, pattern)
+			}
+		case *ArrayLiteralNode:
+			for _, element := range pattern.Elements {
+				collect(element)
+			}
+		case *StructLiteralNode:
+			for _, value := range pattern.Values {
+				collect(value)
+			}
+		case *HashPatternNode:
+			for _, value := range pattern.Values {
+				collect(value)
+			}
+		}
+	}
+	collect(ma.Pattern)
+	return bindings
+}
+
+// HashPatternNode is a type for representing the patterns of match arms that match hashes by their keys in AST.
+// ex:- {"name": n, "age": 30}
+type HashPatternNode struct {
+	Token    token.Token // the "{" token
+	Keys     []*StringLiteralNode
+	Values   []ExpressionNode // the patterns the values of the Keys have to match
+	EndToken token.Token      // the "}" token
+}
+
+// TokenLiteral returns the HashPatternNode's token literal.
+func (hp *HashPatternNode) TokenLiteral() string         { return hp.Token.Literal }
+func (hp *HashPatternNode) Position() (line, column int) { return hp.Token.Line, hp.Token.Column }
+func (hp *HashPatternNode) expressionNode()              {}
+func (hp *HashPatternNode) String() string {
+	pairs := []string{}
+	for idx, key := range hp.Keys {
+		pairs = append(pairs, key.String()+": "+hp.Values[idx].String())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
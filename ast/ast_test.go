@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/shksa/yeezy/token"
@@ -27,3 +28,72 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() is wrong. got=%q \n", program.String())
 	}
 }
+
+func TestDump(t *testing.T) {
+	program := &Program{
+		Statements: []StatementNode{
+			&LetStatementNode{
+				Token: token.Token{Type: "LET", Literal: "let", Line: 1, Column: 1},
+				Iden: &IdentifierNode{
+					Token: token.Token{Type: "IDENTIFIER", Literal: "x", Line: 1, Column: 5},
+					Name:  "x",
+				},
+				Value: &IntegerLiteralNode{
+					Token: token.Token{Type: "INT", Literal: "5", Line: 1, Column: 9},
+					Value: 5,
+				},
+			},
+		},
+	}
+
+	expected := "Program\n" +
+		"  LetStatementNode 1:1 \"let\"\n" +
+		"    Iden: IdentifierNode 1:5 \"x\"\n" +
+		"    Value: IntegerLiteralNode 1:9 \"5\"\n"
+
+	if Dump(program) != expected {
+		t.Errorf("Dump(program) is wrong. got=%q", Dump(program))
+	}
+}
+
+func TestInspect(t *testing.T) {
+	program := &Program{
+		Statements: []StatementNode{
+			&ExpressionStatementNode{
+				Expression: &InfixExpressionNode{
+					Left:     &IdentifierNode{Name: "x"},
+					Operator: "+",
+					Right: &CallExpressionNode{
+						Function:  &IdentifierNode{Name: "f"},
+						Arguments: []ExpressionNode{&IdentifierNode{Name: "y"}},
+					},
+				},
+			},
+		},
+	}
+
+	names := []string{}
+	Inspect(program, func(node Node) bool {
+		if iden, ok := node.(*IdentifierNode); ok {
+			names = append(names, iden.Name)
+		}
+		_, isCall := node.(*CallExpressionNode)
+		return !isCall
+	})
+
+	if strings.Join(names, " ") != "x" {
+		t.Errorf("Inspect visited the wrong identifiers. got=%v", names)
+	}
+
+	names = names[:0]
+	Inspect(program, func(node Node) bool {
+		if iden, ok := node.(*IdentifierNode); ok {
+			names = append(names, iden.Name)
+		}
+		return true
+	})
+
+	if strings.Join(names, " ") != "x f y" {
+		t.Errorf("Inspect visited the wrong identifiers. got=%v", names)
+	}
+}
@@ -0,0 +1,69 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// Dump returns the tree of a node in string format, one node per line with its children indented under it.
+// Unlike String, which outputs the node back as source code, Dump shows the type and the position of every node.
+// ex:- for `let x = 1 + 2`
+//
+//	Program
+//	  LetStatementNode 1:1 "let"
+//	    Iden: IdentifierNode 1:5 "x"
+//	    Value: InfixExpressionNode 1:11 "+"
+//	      Left: IntegerLiteralNode 1:9 "1"
+//	      Right: IntegerLiteralNode 1:13 "2"
+func Dump(node Node) string {
+	var out bytes.Buffer
+	dumpNode(&out, node, "", 0)
+	return out.String()
+}
+
+func dumpNode(out *bytes.Buffer, node Node, label string, depth int) {
+	value := reflect.ValueOf(node)
+	if node == nil || (value.Kind() == reflect.Ptr && value.IsNil()) {
+		return
+	}
+
+	out.WriteString(strings.Repeat("  ", depth))
+	if label != "" {
+		out.WriteString(label + ": ")
+	}
+
+	structValue := reflect.Indirect(value)
+	out.WriteString(structValue.Type().Name())
+	if _, isProgram := node.(*Program); !isProgram {
+		line, column := node.Position()
+		fmt.Fprintf(out, " %d:%d %q", line, column, node.TokenLiteral())
+	}
+	out.WriteString("\n")
+
+	for idx := 0; idx < structValue.NumField(); idx++ {
+		field := structValue.Field(idx)
+		fieldName := structValue.Type().Field(idx).Name
+
+		switch {
+		case field.Type().Implements(nodeType):
+			if child, ok := field.Interface().(Node); ok {
+				dumpNode(out, child, fieldName, depth+1)
+			}
+
+		case field.Kind() == reflect.Slice && field.Type().Elem().Implements(nodeType):
+			for elIdx := 0; elIdx < field.Len(); elIdx++ {
+				if child, ok := field.Index(elIdx).Interface().(Node); ok {
+					label := fmt.Sprintf("%s[%d]", fieldName, elIdx)
+					if _, isProgram := node.(*Program); isProgram {
+						label = ""
+					}
+					dumpNode(out, child, label, depth+1)
+				}
+			}
+		}
+	}
+}
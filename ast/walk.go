@@ -0,0 +1,36 @@
+package ast
+
+import "reflect"
+
+// Inspect traverses the tree of a node in depth-first order, children in the order they appear in the source code.
+// It calls fn for every node, starting with the given node, and does not visit the children of a node for which
+// fn returns false.
+func Inspect(node Node, fn func(Node) bool) {
+	value := reflect.ValueOf(node)
+	if node == nil || (value.Kind() == reflect.Ptr && value.IsNil()) {
+		return
+	}
+
+	if !fn(node) {
+		return
+	}
+
+	structValue := reflect.Indirect(value)
+	for idx := 0; idx < structValue.NumField(); idx++ {
+		field := structValue.Field(idx)
+
+		switch {
+		case field.Type().Implements(nodeType):
+			if child, ok := field.Interface().(Node); ok {
+				Inspect(child, fn)
+			}
+
+		case field.Kind() == reflect.Slice && field.Type().Elem().Implements(nodeType):
+			for elIdx := 0; elIdx < field.Len(); elIdx++ {
+				if child, ok := field.Index(elIdx).Interface().(Node); ok {
+					Inspect(child, fn)
+				}
+			}
+		}
+	}
+}
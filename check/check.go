@@ -0,0 +1,455 @@
+// Package check finds mistakes in yeezy programs without running them, the way `yeezy check` does.
+//
+// It resolves every identifier to the let, const or struct statement, function parameter, catch parameter, value
+// received by a select case, name bound by a match pattern, import or built-in function that defines it, and reports
+// identifiers that are not defined, bindings that are never used, bindings that shadow another one, constants that are
+// bound again, struct literals and patterns with the wrong fields, and calls of function literals and struct types with
+// the wrong number of arguments.
+package check
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shksa/yeezy/ast"
+)
+
+// Severity is a type for representing how bad a problem is.
+type Severity string
+
+// The severities of the problems, an Error fails when the program runs, a Warning is likely a mistake.
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Diagnostic is a type for representing a problem found in a program.
+type Diagnostic struct {
+	Line     int
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message)
+}
+
+// BindingKind is a type for representing what defines a binding.
+type BindingKind string
+
+// The kinds of bindings.
+const (
+	Let       BindingKind = "let"
+	Const     BindingKind = "constant"
+	Struct    BindingKind = "struct"
+	Parameter BindingKind = "parameter"
+	Catch     BindingKind = "catch parameter"
+	Received  BindingKind = "received value"
+	Matched   BindingKind = "matched value"
+	Import    BindingKind = "import"
+	Builtin   BindingKind = "built-in function"
+)
+
+// Binding is a type for representing a name defined in a program.
+type Binding struct {
+	Name string
+	Kind BindingKind
+	// Node is the node that defines the binding: the *ast.LetStatementNode, *ast.StructStatementNode,
+	// *ast.FunctionLiteralNode, *ast.TryExpressionNode, *ast.SelectCaseNode, *ast.MatchArmNode or
+	// *ast.ImportStatementNode. It is nil for built-in functions.
+	Node ast.Node
+	// Iden is the identifier that names the binding where it is defined. It is nil for built-in functions, and for
+	// imports without an alias, which are named after the imported file.
+	Iden     *ast.IdentifierNode
+	Exported bool
+	Uses     []*ast.IdentifierNode
+}
+
+// Position returns the position of the binding's definition.
+func (b *Binding) Position() (line, column int) {
+	if b.Iden != nil {
+		return b.Iden.Position()
+	}
+	if b.Node != nil {
+		return b.Node.Position()
+	}
+	return 0, 0
+}
+
+// Result is a type for representing what the checker found out about a program.
+type Result struct {
+	Diagnostics []Diagnostic // sorted by position
+	Bindings    []*Binding   // all the bindings the program defines, in the order they are defined
+	// Identifiers maps the identifiers of the program, where the bindings are defined as well as where they are used,
+	// to their bindings. Identifiers that are not defined are not in it.
+	Identifiers map[*ast.IdentifierNode]*Binding
+}
+
+// Program checks a program. builtins are the names of the built-in functions the program can call.
+func Program(program *ast.Program, builtins []string) *Result {
+	c := &checker{result: &Result{Identifiers: make(map[*ast.IdentifierNode]*Binding)}}
+
+	c.scope = newScope(nil, true)
+	for _, name := range builtins {
+		c.scope.names[name] = &Binding{Name: name, Kind: Builtin}
+	}
+
+	c.scope = newScope(c.scope, true)
+	c.checkStatements(program.Statements)
+	c.closeScope()
+
+	sort.SliceStable(c.result.Diagnostics, func(i, j int) bool {
+		di, dj := c.result.Diagnostics[i], c.result.Diagnostics[j]
+		return di.Line < dj.Line || di.Line == dj.Line && di.Column < dj.Column
+	})
+	return c.result
+}
+
+// scope is a type for representing the names visible in a function body, a program, a catch block, a select case or
+// a match arm.
+// Like an object.Environment, the blocks of if expressions share the scope they are in.
+type scope struct {
+	outer    *scope
+	names    map[string]*Binding
+	bindings []*Binding // including the ones that were defined again with the same name, and the ones of inner scopes
+	function bool       // the scope of a function body or of the whole program
+	// deferred are the function literals of the scope whose bodies are not checked yet. Bodies are checked after the
+	// rest of the function they are in, because they run later and can use the names defined after them.
+	deferred []deferredFunction
+}
+
+type deferredFunction struct {
+	function *ast.FunctionLiteralNode
+	scope    *scope
+}
+
+func newScope(outer *scope, function bool) *scope {
+	return &scope{outer: outer, names: make(map[string]*Binding), function: function}
+}
+
+func (s *scope) lookup(name string) (*Binding, bool) {
+	for sc := s; sc != nil; sc = sc.outer {
+		if binding, ok := sc.names[name]; ok {
+			return binding, true
+		}
+	}
+	return nil, false
+}
+
+// checker is a type for holding the state of checking one program.
+type checker struct {
+	result *Result
+	scope  *scope
+}
+
+func (c *checker) report(node ast.Node, severity Severity, format string, a ...interface{}) {
+	line, column := node.Position()
+	c.result.Diagnostics = append(c.result.Diagnostics, Diagnostic{line, column, severity, fmt.Sprintf(format, a...)})
+}
+
+func (c *checker) define(binding *Binding) {
+	at := binding.Node
+	if binding.Iden != nil {
+		at = binding.Iden
+	}
+	if previous := c.scope.names[binding.Name]; previous != nil && previous.Kind == Const {
+		line, _ := previous.Position()
+		c.report(at, Error, "cannot bind %s again, it is a constant defined on line %d", binding.Name, line)
+	}
+	if outer, ok := c.scope.outer.lookup(binding.Name); ok && c.scope.names[binding.Name] == nil {
+		if outer.Kind == Builtin {
+			c.report(at, Warning, "%s shadows the built-in function %s", binding.Name, binding.Name)
+		} else {
+			line, _ := outer.Position()
+			c.report(at, Warning, "%s shadows the %s %s on line %d", binding.Name, outer.Kind, binding.Name, line)
+		}
+	}
+
+	c.scope.names[binding.Name] = binding
+	c.scope.bindings = append(c.scope.bindings, binding)
+	c.result.Bindings = append(c.result.Bindings, binding)
+	if binding.Iden != nil {
+		c.result.Identifiers[binding.Iden] = binding
+	}
+}
+
+// closeScope checks the deferred function bodies of the current scope, reports its unused bindings and returns to the
+// outer scope. The bindings of a scope that is not a function scope are reported with the ones of the function scope it
+// is in, as the function literals that can use them are only checked when that scope closes.
+func (c *checker) closeScope() {
+	current := c.scope
+	if !current.function {
+		owner := current.outer
+		for !owner.function {
+			owner = owner.outer
+		}
+		owner.bindings = append(owner.bindings, current.bindings...)
+		c.scope = current.outer
+		return
+	}
+
+	for len(current.deferred) > 0 {
+		deferred := current.deferred[0]
+		current.deferred = current.deferred[1:]
+
+		c.scope = newScope(deferred.scope, true)
+		for _, param := range deferred.function.Parameters {
+			c.define(&Binding{Name: param.Name, Kind: Parameter, Node: deferred.function, Iden: param})
+		}
+		c.checkStatements(deferred.function.Body.Statements)
+		c.closeScope()
+	}
+	c.scope = current
+
+	for _, binding := range current.bindings {
+		if len(binding.Uses) == 0 && !binding.Exported && !strings.HasPrefix(binding.Name, "_") {
+			at := binding.Node
+			if binding.Iden != nil {
+				at = binding.Iden
+			}
+			c.report(at, Warning, "%s %s is never used", binding.Kind, binding.Name)
+		}
+	}
+
+	c.scope = current.outer
+}
+
+func (c *checker) checkStatements(stmts []ast.StatementNode) {
+	for _, stmt := range stmts {
+		c.checkStatement(stmt)
+	}
+}
+
+func (c *checker) checkStatement(stmt ast.StatementNode) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatementNode:
+		c.checkLetStatement(stmt)
+	case *ast.ExportStatementNode:
+		c.checkLetStatement(stmt.Statement).Exported = true
+	case *ast.ReturnStatementNode:
+		c.checkExpression(stmt.ReturnValue)
+	case *ast.ThrowStatementNode:
+		c.checkExpression(stmt.Value)
+	case *ast.StructStatementNode:
+		c.define(&Binding{Name: stmt.Name.Name, Kind: Struct, Node: stmt, Iden: stmt.Name})
+	case *ast.AssignStatementNode:
+		c.checkExpression(stmt.Target)
+		c.checkExpression(stmt.Value)
+	case *ast.ExpressionStatementNode:
+		c.checkExpression(stmt.Expression)
+	case *ast.BlockStatementNode:
+		c.checkStatements(stmt.Statements)
+	case *ast.ImportStatementNode:
+		binding := &Binding{Kind: Import, Node: stmt, Iden: stmt.Alias}
+		if stmt.Alias != nil {
+			binding.Name = stmt.Alias.Name
+		} else {
+			binding.Name = strings.TrimSuffix(filepath.Base(stmt.Path.Value), filepath.Ext(stmt.Path.Value))
+		}
+		c.define(binding)
+	}
+}
+
+func (c *checker) checkLetStatement(stmt *ast.LetStatementNode) *Binding {
+	c.checkExpression(stmt.Value)
+	binding := &Binding{Name: stmt.Iden.Name, Kind: Let, Node: stmt, Iden: stmt.Iden}
+	if stmt.IsConst() {
+		binding.Kind = Const
+	}
+	c.define(binding)
+	return binding
+}
+
+func (c *checker) checkExpression(expr ast.ExpressionNode) {
+	switch expr := expr.(type) {
+	case *ast.IdentifierNode:
+		binding, ok := c.scope.lookup(expr.Name)
+		if !ok {
+			c.report(expr, Error, "identifier not found: %s", expr.Name)
+			return
+		}
+		binding.Uses = append(binding.Uses, expr)
+		c.result.Identifiers[expr] = binding
+	case *ast.PrefixExpressionNode:
+		c.checkExpression(expr.Right)
+	case *ast.InfixExpressionNode:
+		c.checkExpression(expr.Left)
+		c.checkExpression(expr.Right)
+	case *ast.CallExpressionNode:
+		c.checkExpression(expr.Function)
+		for _, arg := range expr.Arguments {
+			c.checkExpression(arg)
+		}
+		c.checkArity(expr)
+	case *ast.IndexExpressionNode:
+		c.checkExpression(expr.Left)
+		c.checkExpression(expr.Index)
+	case *ast.MemberExpressionNode:
+		c.checkExpression(expr.Object) // the property is looked up in the object, not in the scope
+	case *ast.PropagateExpressionNode:
+		c.checkExpression(expr.Value)
+	case *ast.ArrayLiteralNode:
+		for _, element := range expr.Elements {
+			c.checkExpression(element)
+		}
+	case *ast.HashLiteralNode:
+		for idx, key := range expr.Keys {
+			c.checkExpression(key)
+			c.checkExpression(expr.Values[idx])
+		}
+	case *ast.StructLiteralNode:
+		c.checkExpression(expr.Type)
+		for _, value := range expr.Values {
+			c.checkExpression(value)
+		}
+		c.checkStructFields(expr, false)
+	case *ast.IfExpressionNode:
+		c.checkExpression(expr.Condition)
+		c.checkStatement(expr.Consequence)
+		if expr.Alternative != nil {
+			c.checkStatement(expr.Alternative)
+		}
+	case *ast.FunctionLiteralNode:
+		functionScope := c.scope
+		for !functionScope.function {
+			functionScope = functionScope.outer
+		}
+		functionScope.deferred = append(functionScope.deferred, deferredFunction{expr, c.scope})
+	case *ast.TryExpressionNode:
+		c.checkStatement(expr.Block)
+		if expr.Catch != nil {
+			c.scope = newScope(c.scope, false)
+			if expr.CatchParam != nil {
+				c.define(&Binding{Name: expr.CatchParam.Name, Kind: Catch, Node: expr, Iden: expr.CatchParam})
+			}
+			c.checkStatement(expr.Catch)
+			c.closeScope()
+		}
+		if expr.Finally != nil {
+			c.checkStatement(expr.Finally)
+		}
+	case *ast.SpawnExpressionNode:
+		c.checkExpression(expr.Call)
+	case *ast.SelectExpressionNode:
+		for _, selectCase := range expr.Cases {
+			c.checkExpression(selectCase.Operation)
+			if selectCase.Name == nil {
+				c.checkStatement(selectCase.Body)
+				continue
+			}
+			c.scope = newScope(c.scope, false)
+			c.define(&Binding{Name: selectCase.Name.Name, Kind: Received, Node: selectCase, Iden: selectCase.Name})
+			c.checkStatement(selectCase.Body)
+			c.closeScope()
+		}
+		if expr.Default != nil {
+			c.checkStatement(expr.Default)
+		}
+	case *ast.MatchExpressionNode:
+		c.checkExpression(expr.Value)
+		for _, arm := range expr.Arms {
+			c.checkPattern(arm.Pattern)
+			c.scope = newScope(c.scope, false)
+			for _, iden := range arm.Bindings() {
+				c.define(&Binding{Name: iden.Name, Kind: Matched, Node: arm, Iden: iden})
+			}
+			if arm.Body != nil {
+				c.checkStatement(arm.Body)
+			} else {
+				c.checkExpression(arm.Value)
+			}
+			c.closeScope()
+		}
+	}
+}
+
+// checkPattern checks the struct types of a match pattern and their fields, the names the pattern binds are defined by
+// the arm it is in.
+func (c *checker) checkPattern(pattern ast.ExpressionNode) {
+	switch pattern := pattern.(type) {
+	case *ast.ArrayLiteralNode:
+		for _, element := range pattern.Elements {
+			c.checkPattern(element)
+		}
+	case *ast.StructLiteralNode:
+		c.checkExpression(pattern.Type)
+		for _, value := range pattern.Values {
+			c.checkPattern(value)
+		}
+		c.checkStructFields(pattern, true)
+	case *ast.HashPatternNode:
+		for _, value := range pattern.Values {
+			c.checkPattern(value)
+		}
+	}
+}
+
+// checkStructFields reports the fields of a struct literal that its struct does not have, and the ones it leaves out
+// unless it is a pattern, which does not have to list every field.
+func (c *checker) checkStructFields(literal *ast.StructLiteralNode, pattern bool) {
+	binding := c.result.Identifiers[literal.Type]
+	if binding == nil || binding.Kind != Struct {
+		return
+	}
+	structStmt := binding.Node.(*ast.StructStatementNode)
+
+	given := make(map[string]bool)
+	for _, field := range literal.Fields {
+		given[field.Name] = true
+		found := false
+		for _, structField := range structStmt.Fields {
+			found = found || structField.Name == field.Name
+		}
+		if !found {
+			c.report(field, Error, "%s has no field %s", literal.Type.Name, field.Name)
+		}
+	}
+	for _, structField := range structStmt.Fields {
+		if !pattern && !given[structField.Name] {
+			c.report(literal, Error, "%s{...} has no value for the field %s", literal.Type.Name, structField.Name)
+		}
+	}
+}
+
+// checkArity reports a call of a function literal, of a name bound to one, or of a struct type, with the wrong number
+// of arguments. Too many arguments for a function are only a warning, as a function ignores the ones it has no
+// parameters for.
+func (c *checker) checkArity(call *ast.CallExpressionNode) {
+	name := "function"
+	function, ok := call.Function.(*ast.FunctionLiteralNode)
+	if iden, isIden := call.Function.(*ast.IdentifierNode); isIden {
+		binding := c.result.Identifiers[iden]
+		if binding != nil && binding.Kind == Struct {
+			fields := binding.Node.(*ast.StructStatementNode).Fields
+			if len(fields) != len(call.Arguments) {
+				c.report(call.Function, Error, "%s takes %s, but is called with %d", iden.Name, pluralize(len(fields), "argument"), len(call.Arguments))
+			}
+			return
+		}
+		if binding == nil || binding.Kind != Let && binding.Kind != Const {
+			return
+		}
+		name = iden.Name
+		function, ok = binding.Node.(*ast.LetStatementNode).Value.(*ast.FunctionLiteralNode)
+	}
+
+	if !ok || len(function.Parameters) == len(call.Arguments) {
+		return
+	}
+
+	severity := Error
+	if len(call.Arguments) > len(function.Parameters) { // the extra arguments are left out when the function runs
+		severity = Warning
+	}
+	c.report(call.Function, severity, "%s takes %s, but is called with %d", name, pluralize(len(function.Parameters), "argument"), len(call.Arguments))
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,122 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/parser"
+)
+
+func testCheck(t *testing.T, input string) *Result {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		t.Fatalf("parse errors in %q: %v", input, p.Errors)
+	}
+	return Program(program, []string{"len", "print", "channel", "receive", "send"})
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x + len(\"a\")", []string{}},
+		{"x", []string{"1:1: error: identifier not found: x"}},
+		{"let x = y; let y = 1; x + y", []string{"1:9: error: identifier not found: y"}},
+		{"let f = func() { g() }; let g = func() { 1 }; f()", []string{}},
+		{"let f = func(n) { if (n < 1) { 0 } else { f(n - 1) } }; f(3)", []string{}},
+		{"let x = 1; 2", []string{"1:5: warning: let x is never used"}},
+		{"let f = func(a, _b) { 1 }; f(1, 2)", []string{"1:14: warning: parameter a is never used"}},
+		{"export let x = 1", []string{}},
+		{"let x = 1; let f = func(x) { x }; f(x)", []string{"1:25: warning: x shadows the let x on line 1"}},
+		{"let len = func(s) { s }; len(1)", []string{"1:5: warning: len shadows the built-in function len"}},
+		{"let x = 1; let x = x + 1; x", []string{}},
+		{"let add = func(a, b) { a + b }; add(1)", []string{"1:33: error: add takes 2 arguments, but is called with 1"}},
+		{"func(a) { a }(1, 2)", []string{"1:1: warning: function takes 1 argument, but is called with 2"}},
+		{"let f = func() { 1 }; let g = f; g(1)", []string{}},
+		{"if (true) { let y = 1 }; y", []string{}},
+		{"try { 1 } catch (e) { e.message }; e", []string{"1:36: error: identifier not found: e"}},
+		{"try { 1 } catch (e) { 2 }", []string{"1:18: warning: catch parameter e is never used"}},
+		{"try { 1 } catch (e) { let f = func() { e.message }; f() }", []string{}},
+		{`import "lib/math.yz"; import "str.yz" as s; math.pi + s.x`, []string{}},
+		{`import "math.yz"`, []string{"1:1: warning: import math is never used"}},
+		{"let m = [1]; m.len() + m[0].x", []string{}},
+		{"let ch = channel(); select { case v = receive(ch) { v } }; v", []string{"1:60: error: identifier not found: v"}},
+		{"let ch = channel(); select { case v = receive(ch) { 1 } default { 2 } }", []string{"1:35: warning: received value v is never used"}},
+		{"let ch = channel(); select { case v = receive(ch) { func() { v } } }", []string{}},
+		{"let f = func(a) { a }; spawn f(1, 2)", []string{"1:30: warning: f takes 1 argument, but is called with 2"}},
+		{"const x = 1; let x = x + 1; x", []string{"1:18: error: cannot bind x again, it is a constant defined on line 1"}},
+		{"const n = 1; if (n > 0) { const n = 2 }; n", []string{"1:33: error: cannot bind n again, it is a constant defined on line 1"}},
+		{"const f = func(a) { a }; f(1, 2)", []string{"1:26: warning: f takes 1 argument, but is called with 2"}},
+		{"const x = 1; let f = func() { let x = 2; x }; f() + x", []string{"1:35: warning: x shadows the constant x on line 1"}},
+		{"export const x = 1", []string{}},
+		{"struct Point { x, y }; let p = Point{x: 1, y: 2}; p.x = Point(p.x, 3)", []string{}},
+		{"struct Point { x, y }; Point{x: 1, z: 2}", []string{"1:24: error: Point{...} has no value for the field y", "1:36: error: Point has no field z"}},
+		{"struct Point { x, y }; Point(1)", []string{"1:24: error: Point takes 2 arguments, but is called with 1"}},
+		{"struct Point { x }", []string{"1:8: warning: struct Point is never used"}},
+		{"q.x = 1", []string{"1:1: error: identifier not found: q"}},
+		{"let v = [1, 2]; match (v) { [a, _] => a, [_b] => 0, _ => 1 }", []string{}},
+		{"match (1) { [a, b] => a }", []string{"1:17: warning: matched value b is never used"}},
+		{"match (5) { n => func() { n } }", []string{}},
+		{"match (5) { n => func() { 1 } }", []string{"1:13: warning: matched value n is never used"}},
+		{"match (1) { a => a, _ => a }", []string{"1:26: error: identifier not found: a"}},
+		{"let a = 1; match (a) { a => a }", []string{"1:24: warning: a shadows the let a on line 1"}},
+		{"struct Point { x, y }; match (1) { Point{x: 0, z: z} => z }", []string{"1:48: error: Point has no field z"}},
+		{"match (1) { Point{x: x} => x }", []string{"1:13: error: identifier not found: Point"}},
+		{`struct Point { x, y }; match ({"x": 1}) { {"x": x} => x, Point{y: y} => { let d = y; d } }`, []string{}},
+		{`let h = {"a": b, c: 1}; h`, []string{"1:15: error: identifier not found: b", "1:18: error: identifier not found: c"}},
+	}
+
+	for _, tt := range tests {
+		result := testCheck(t, tt.input)
+
+		if len(result.Diagnostics) != len(tt.expected) {
+			t.Errorf("%q: wrong number of diagnostics. expected=%v, got=%v", tt.input, tt.expected, result.Diagnostics)
+			continue
+		}
+
+		for i, diagnostic := range result.Diagnostics {
+			if diagnostic.String() != tt.expected[i] {
+				t.Errorf("%q: diagnostics[%d] is wrong. expected=%q, got=%q", tt.input, i, tt.expected[i], diagnostic.String())
+			}
+		}
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	result := testCheck(t, "let x = 1\nlet f = func(y) { x + y }\nf(2)")
+
+	if len(result.Bindings) != 3 {
+		t.Fatalf("wrong number of bindings. expected=3, got=%d", len(result.Bindings))
+	}
+
+	expected := []struct {
+		name string
+		kind BindingKind
+		line int
+		uses int
+	}{
+		{"x", Let, 1, 1},
+		{"f", Let, 2, 1},
+		{"y", Parameter, 2, 1},
+	}
+
+	for i, tt := range expected {
+		binding := result.Bindings[i]
+		line, _ := binding.Position()
+		if binding.Name != tt.name || binding.Kind != tt.kind || line != tt.line || len(binding.Uses) != tt.uses {
+			t.Errorf("bindings[%d] is wrong. expected=%+v, got={%s %s %d %d}", i, tt, binding.Name, binding.Kind, line, len(binding.Uses))
+		}
+
+		for _, use := range binding.Uses {
+			if result.Identifiers[use] != binding {
+				t.Errorf("the use of %s at %v is not resolved to its binding", tt.name, use.Token)
+			}
+		}
+
+		if result.Identifiers[binding.Iden] != binding {
+			t.Errorf("the definition of %s is not resolved to its binding", tt.name)
+		}
+	}
+}
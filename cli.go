@@ -0,0 +1,549 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/check"
+	"github.com/shksa/yeezy/dap"
+	"github.com/shksa/yeezy/debugger"
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/format"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/lsp"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+	"github.com/shksa/yeezy/profile"
+	"github.com/shksa/yeezy/token"
+)
+
+// Exit codes of the yeezy command.
+const (
+	exitOK            = 0
+	exitRuntimeError  = 1 // the program stopped with an error raised by the interpreter
+	exitUsageError    = 2 // the command line was wrong, or a file could not be read
+	exitParseError    = 3 // the program has parse errors
+	exitUncaughtThrow = 4 // the program stopped with an error raised by a throw statement
+	exitUnformatted   = 1 // yeezy fmt -check found files that are not formatted
+	exitProblems      = 1 // yeezy check found problems in the programs
+	exitTestsFailed   = 1 // yeezy test had failing tests
+)
+
+// cliCommand is a type for representing a subcommand of the yeezy command, ex:- yeezy run.
+type cliCommand struct {
+	name  string
+	usage string
+	help  string
+	run   func(args []string, out, errOut io.Writer) int
+}
+
+// cliCommands is the list of all the subcommands, it is filled in init because printUsage refers to it.
+var cliCommands []*cliCommand
+
+func init() {
+	cliCommands = []*cliCommand{
+		{"run", "run [-path dirs] [-profile file] (file.yz | -) [args]", "run a program, - reads it from stdin", runCommand},
+		{"repl", "repl [-path dirs]", "start the interactive repl, same as running yeezy without arguments", replCommand},
+		{"eval", "eval [-path dirs] [-profile file] -e 'src' [args]", "evaluate source code given on the command line and print its value", evalCommand},
+		{"tokens", "tokens (-e 'src' | file.yz)", "print the tokens of a program", tokensCommand},
+		{"ast", "ast (-e 'src' | file.yz)", "print the syntax tree of a program", astCommand},
+		{"debug", "debug [-path dirs] file.yz [args]", "run a program in the debugger, type help when it pauses for the commands", debugCommand},
+		{"check", "check (-e 'src' | files or dirs)", "report undefined names, unused bindings, shadowing and arity mismatches", checkCommand},
+		{"lsp", "lsp", "start a language server for editors, speaking LSP over stdin and stdout", lspCommand},
+		{"fmt", "fmt [-w | -check] [files or dirs]", "format programs, stdin to stdout without files", fmtCommand},
+		{"test", "test [-path dirs] [-cover] [-coverprofile file] [files or dirs]", "run the *_test.yz files, and report the coverage of the other files", testCommand},
+		{"dap", "dap [-path dirs]", "start a debug adapter for editors, speaking DAP over stdin and stdout", dapCommand},
+	}
+}
+
+// runCLI runs the yeezy command with its arguments, and returns the exit code.
+func runCLI(args []string, out, errOut io.Writer) int {
+	if len(args) == 0 {
+		return runREPL()
+	}
+
+	for _, cmd := range cliCommands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], out, errOut)
+		}
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		printUsage(out)
+		return exitOK
+	}
+
+	// `yeezy file.yz [args]` is a short form of `yeezy run file.yz [args]`, which is also how a script with a
+	// "#!/usr/bin/env yeezy" line is run.
+	if info, err := os.Stat(args[0]); args[0] == "-" || err == nil && !info.IsDir() {
+		return runCommand(args, out, errOut)
+	}
+
+	fmt.Fprintf(errOut, "unknown command %q\n", args[0])
+	printUsage(errOut)
+	return exitUsageError
+}
+
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "usage: yeezy <command> [arguments]")
+	fmt.Fprintln(out, "\ncommands:")
+	for _, cmd := range cliCommands {
+		fmt.Fprintf(out, "  %-32s %s\n", cmd.usage, cmd.help)
+	}
+}
+
+// newFlagSet returns a flag set for a subcommand that reports its errors to errOut.
+func newFlagSet(name string, errOut io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	return fs
+}
+
+func addSearchPathFlag(fs *flag.FlagSet) {
+	fs.StringVar(&searchPath, "path", searchPath, "list of directories to look up imported files in, separated by "+string(os.PathListSeparator))
+}
+
+func addProfileFlags(fs *flag.FlagSet) {
+	fs.StringVar(&profilePath, "profile", "", "write the time spent in each function and line of the program to this file")
+	fs.StringVar(&profileFormat, "profileformat", "folded", "format of the profile: folded stacks for flame graphs, pprof, or text")
+}
+
+// parseRunFlags parses the flags of the commands that run programs, and checks the profile format.
+func parseRunFlags(fs *flag.FlagSet, args []string, errOut io.Writer) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch profileFormat {
+	case "folded", "pprof", "text":
+		return nil
+	}
+	err := fmt.Errorf("unknown profile format %q, it is folded, pprof or text", profileFormat)
+	fmt.Fprintln(errOut, err)
+	return err
+}
+
+func runCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("run", errOut)
+	addSearchPathFlag(fs)
+	addProfileFlags(fs)
+	if err := parseRunFlags(fs, args, errOut); err != nil {
+		return exitUsageError
+	}
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(errOut, "usage: yeezy run [-path dirs] [-profile file] (file.yz | -) [args]")
+		return exitUsageError
+	}
+
+	return runProgramFile(fs.Arg(0), fs.Args()[1:], out, errOut)
+}
+
+func replCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("repl", errOut)
+	addSearchPathFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsageError
+	}
+
+	return runREPL()
+}
+
+func evalCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("eval", errOut)
+	addSearchPathFlag(fs)
+	addProfileFlags(fs)
+	src := fs.String("e", "", "source code to evaluate")
+	if err := parseRunFlags(fs, args, errOut); err != nil {
+		return exitUsageError
+	}
+
+	if *src == "" {
+		fmt.Fprintln(errOut, "usage: yeezy eval [-path dirs] [-profile file] -e 'src' [args]")
+		return exitUsageError
+	}
+
+	return runProgram(*src, "", fs.Args(), out, errOut)
+}
+
+func tokensCommand(args []string, out, errOut io.Writer) int {
+	src, _, code := readSourceArgs("tokens", args, errOut)
+	if code != exitOK {
+		return code
+	}
+
+	l := lexer.New(src)
+	for tok := l.NextToken(); tok.Type != token.EOF.Type; tok = l.NextToken() {
+		fmt.Fprintf(out, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+	}
+	return exitOK
+}
+
+func astCommand(args []string, out, errOut io.Writer) int {
+	src, _, code := readSourceArgs("ast", args, errOut)
+	if code != exitOK {
+		return code
+	}
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		printParseErrors(errOut, p.Errors)
+		return exitParseError
+	}
+
+	io.WriteString(out, ast.Dump(program))
+	return exitOK
+}
+
+func fmtCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("fmt", errOut)
+	write := fs.Bool("w", false, "write the formatted source code back to the files instead of printing it")
+	check := fs.Bool("check", false, "only list the files that are not formatted, and exit with 1 if there are any")
+	if err := fs.Parse(args); err != nil {
+		return exitUsageError
+	}
+
+	if fs.NArg() == 0 {
+		src, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			return exitUsageError
+		}
+		formatted, err := format.Source(string(src))
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			return exitParseError
+		}
+		if *check {
+			if formatted != string(src) {
+				fmt.Fprintln(out, "<stdin>")
+				return exitUnformatted
+			}
+			return exitOK
+		}
+		io.WriteString(out, formatted)
+		return exitOK
+	}
+
+	filePaths, err := sourceFiles(fs.Args())
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitUsageError
+	}
+
+	code := exitOK
+	for _, filePath := range filePaths {
+		src, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			code = exitUsageError
+			continue
+		}
+
+		formatted, err := format.Source(string(src))
+		if err != nil {
+			fmt.Fprintf(errOut, "%s: %s\n", filePath, err)
+			code = exitParseError
+			continue
+		}
+
+		switch {
+		case *check:
+			if formatted != string(src) {
+				fmt.Fprintln(out, filePath)
+				if code == exitOK {
+					code = exitUnformatted
+				}
+			}
+		case *write:
+			if formatted != string(src) {
+				if err := ioutil.WriteFile(filePath, []byte(formatted), 0644); err != nil {
+					fmt.Fprintln(errOut, err)
+					code = exitUsageError
+				}
+			}
+		default:
+			io.WriteString(out, formatted)
+		}
+	}
+	return code
+}
+
+func debugCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("debug", errOut)
+	addSearchPathFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsageError
+	}
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(errOut, "usage: yeezy debug [-path dirs] file.yz [args]")
+		return exitUsageError
+	}
+
+	filePath := fs.Arg(0)
+	src, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitUsageError
+	}
+
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		printParseErrors(errOut, p.Errors)
+		return exitParseError
+	}
+
+	d := debugger.New(newInterpreter(fs.Args()[1:]), program)
+	d.StopOnEntry = true
+	debugger.NewConsole(d, string(src), stdin, out)
+
+	evaluated, quit := d.Run(filePath, object.NewEnvironment())
+	if quit {
+		fmt.Fprintln(out, "program stopped")
+		return exitOK
+	}
+
+	if errObj, ok := evaluated.(*object.Error); ok {
+		return printRuntimeError(errOut, errObj)
+	}
+
+	if evaluated != nil {
+		fmt.Fprintln(out, evaluated.Inspect())
+	}
+	return exitOK
+}
+
+func checkCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("check", errOut)
+	expr := fs.String("e", "", "source code, instead of files")
+	if err := fs.Parse(args); err != nil {
+		return exitUsageError
+	}
+
+	if *expr == "" && fs.NArg() == 0 {
+		fmt.Fprintln(errOut, "usage: yeezy check (-e 'src' | files or dirs)")
+		return exitUsageError
+	}
+
+	builtins := newInterpreter(nil).BuiltinNames()
+	code := exitOK
+	checkSource := func(src, name string) {
+		p := parser.New(lexer.New(src))
+		program := p.ParseProgram()
+		if len(p.Errors) != 0 {
+			for _, errMsg := range p.Errors {
+				fmt.Fprintf(out, "%s: parse error: %s\n", name, errMsg)
+			}
+			code = exitParseError
+			return
+		}
+
+		for _, diagnostic := range check.Program(program, builtins).Diagnostics {
+			fmt.Fprintf(out, "%s:%s\n", name, diagnostic)
+			if code == exitOK {
+				code = exitProblems
+			}
+		}
+	}
+
+	if *expr != "" {
+		checkSource(*expr, "<eval>")
+		return code
+	}
+
+	filePaths, err := sourceFiles(fs.Args())
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitUsageError
+	}
+
+	for _, filePath := range filePaths {
+		src, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			return exitUsageError
+		}
+		checkSource(string(src), filePath)
+	}
+	return code
+}
+
+func dapCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("dap", errOut)
+	addSearchPathFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsageError
+	}
+
+	// The messages of the editor come on stdin, so programs read nothing from it.
+	newDebuggeeInterpreter := func(scriptArgs []string) *evaluator.Interpreter {
+		return newInterpreterReading(scriptArgs, strings.NewReader(""))
+	}
+	if err := dap.New(stdin, out, newDebuggeeInterpreter).Serve(); err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitRuntimeError
+	}
+	return exitOK
+}
+
+func lspCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("lsp", errOut)
+	addSearchPathFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsageError
+	}
+
+	if err := lsp.New(stdin, out, newInterpreter(nil).BuiltinNames()).Serve(); err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitRuntimeError
+	}
+	return exitOK
+}
+
+// sourceFiles returns the files given as arguments, with the directories replaced by the .yz files in them.
+func sourceFiles(args []string) ([]string, error) {
+	filePaths := []string{}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			filePaths = append(filePaths, arg)
+			continue
+		}
+
+		err = filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.HasSuffix(path, ".yz") {
+				filePaths = append(filePaths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filePaths, nil
+}
+
+// readSourceArgs returns the source code given with the -e flag, or else read from the file given as the only argument.
+func readSourceArgs(name string, args []string, errOut io.Writer) (src, filePath string, code int) {
+	fs := newFlagSet(name, errOut)
+	expr := fs.String("e", "", "source code, instead of a file")
+	if err := fs.Parse(args); err != nil {
+		return "", "", exitUsageError
+	}
+
+	if *expr != "" {
+		return *expr, "", exitOK
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintf(errOut, "usage: yeezy %s (-e 'src' | file.yz)\n", name)
+		return "", "", exitUsageError
+	}
+
+	fileContent, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return "", "", exitUsageError
+	}
+
+	return string(fileContent), fs.Arg(0), exitOK
+}
+
+// runProgram parses and evaluates a program, printing its value to out and its errors to errOut.
+// filePath is the file the program was read from, imports are resolved relative to it. It is empty for source code
+// that was not read from a file.
+func runProgram(src, filePath string, scriptArgs []string, out, errOut io.Writer) int {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+
+	if len(p.Errors) != 0 {
+		printParseErrors(errOut, p.Errors)
+		return exitParseError
+	}
+
+	interpreter := newInterpreter(scriptArgs)
+	env := object.NewEnvironment()
+
+	var profiler *profile.Profiler
+	if profilePath != "" {
+		profiler = profile.New(interpreter)
+	}
+
+	var evaluated object.Object
+	if filePath != "" {
+		evaluated = interpreter.EvalFile(filePath, program, env)
+	} else {
+		evaluated = interpreter.Eval(program, env)
+	}
+
+	if profiler != nil {
+		profiler.Stop()
+		if err := writeProfile(profiler); err != nil {
+			fmt.Fprintln(errOut, err)
+			return exitUsageError
+		}
+	}
+
+	if errObj, ok := evaluated.(*object.Error); ok {
+		return printRuntimeError(errOut, errObj)
+	}
+
+	if evaluated != nil {
+		fmt.Fprintln(out, evaluated.Inspect())
+	}
+	return exitOK
+}
+
+// writeProfile writes the profile of a program to profilePath, in profileFormat.
+func writeProfile(profiler *profile.Profiler) error {
+	file, err := os.Create(profilePath)
+	if err != nil {
+		return err
+	}
+
+	switch profileFormat {
+	case "pprof":
+		err = profiler.WritePprof(file)
+	case "text":
+		err = profiler.WriteText(file)
+	default:
+		err = profiler.WriteFolded(file)
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// printRuntimeError prints an error that stopped a program with its stack, and returns the exit code for it.
+func printRuntimeError(errOut io.Writer, errObj *object.Error) int {
+	if errObj.Thrown {
+		io.WriteString(errOut, "Uncaught ")
+	}
+	fmt.Fprintln(errOut, errObj.Inspect())
+	for _, frame := range errObj.Stack {
+		fmt.Fprintln(errOut, "\t"+frame)
+	}
+
+	if errObj.Thrown {
+		return exitUncaughtThrow
+	}
+	return exitRuntimeError
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shksa/yeezy/object"
+)
+
+func TestCLIExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	program := filepath.Join(dir, "main.yz")
+	if err := ioutil.WriteFile(program, []byte("let double = func(x) { x * 2 }\ndouble(21)"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args         []string
+		expectedCode int
+		expectedOut  string
+		expectedErr  string
+	}{
+		{[]string{"eval", "-e", "1 + 2"}, exitOK, "3\n", ""},
+		{[]string{"eval", "-e", "let x = 1"}, exitOK, "", ""},
+		{[]string{"eval", "-e", "1 + true"}, exitRuntimeError, "", `Error: operand type mismatch for operator "+" : INTEGER + BOOLEAN`},
+		{[]string{"eval", "-e", `throw "boom"`}, exitUncaughtThrow, "", "Uncaught Error: boom\n\tat <program> (line 1)"},
+		{[]string{"eval", "-e", "let = 5"}, exitParseError, "", "parse errors:"},
+		{[]string{"eval"}, exitUsageError, "", "usage: yeezy eval"},
+		{[]string{"eval", "-e", "", "x"}, exitUsageError, "", "usage: yeezy eval"},
+		{[]string{"eval", "-e", "let s = \"abc\nlet t = 1;\n"}, exitParseError, "", "unterminated string, it has no closing quote"},
+		{[]string{"run", program}, exitOK, "42\n", ""},
+		{[]string{program}, exitOK, "42\n", ""},
+		{[]string{"run"}, exitUsageError, "", "usage: yeezy run"},
+		{[]string{"run", "-profile", filepath.Join(dir, "main.folded"), program}, exitOK, "42\n", ""},
+		{[]string{"run", "-profile", filepath.Join(dir, "main.prof"), "-profileformat", "svg", program}, exitUsageError, "", `unknown profile format "svg"`},
+		{[]string{"frobnicate"}, exitUsageError, "", `unknown command "frobnicate"`},
+		{[]string{"check", "-e", "let f = func(a) { a }; f(1)"}, exitOK, "", ""},
+		{[]string{"check", "-e", "let f = func(a) { b }; f(1, 2)"}, exitProblems, "<eval>:1:14: warning: parameter a is never used\n<eval>:1:19: error: identifier not found: b\n<eval>:1:24: warning: f takes 1 argument, but is called with 2\n", ""},
+		{[]string{"check", "-e", "args()"}, exitOK, "", ""},
+		{[]string{"check", "-e", "let f = func() { export let x = 1 }"}, exitParseError, "<eval>: parse error: export is only allowed at the top level of a module\n", ""},
+		{[]string{"check", program}, exitOK, "", ""},
+		{[]string{"tokens", "-e", "let x"}, exitOK, "1:1\tLET\t\"let\"\n1:5\tIDENTIFIER\t\"x\"\n", ""},
+		{[]string{"ast", "-e", "x"}, exitOK, "Program\n  ExpressionStatementNode 1:1 \"x\"\n    Expression: IdentifierNode 1:1 \"x\"\n", ""},
+	}
+
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+		code := runCLI(tt.args, &out, &errOut)
+
+		if code != tt.expectedCode {
+			t.Errorf("%v: wrong exit code. expected=%d, got=%d (stderr=%q)", tt.args, tt.expectedCode, code, errOut.String())
+		}
+
+		if out.String() != tt.expectedOut {
+			t.Errorf("%v: wrong output. expected=%q, got=%q", tt.args, tt.expectedOut, out.String())
+		}
+
+		if !strings.Contains(errOut.String(), tt.expectedErr) {
+			t.Errorf("%v: wrong error output. expected to contain %q, got=%q", tt.args, tt.expectedErr, errOut.String())
+		}
+	}
+
+	folded, err := ioutil.ReadFile(filepath.Join(dir, "main.folded"))
+	if err != nil || !strings.Contains(string(folded), "\nmain;double ") {
+		t.Errorf("wrong profile written by run -profile. got=%q (err=%v)", folded, err)
+	}
+}
+
+func TestScriptArgumentsAndStdin(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "greet")
+	src := "#!/usr/bin/env yeezy\nlet name = readLine()\nargs().join(\" \") + \", \" + name + \" \" + getenv(\"YEEZY_TEST_VAR\")"
+	if err := ioutil.WriteFile(script, []byte(src), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("YEEZY_TEST_VAR", "!")
+
+	defer func(original io.Reader) { stdin = original }(stdin)
+
+	tests := []struct {
+		args        []string
+		stdin       string
+		expectedOut string
+	}{
+		{[]string{script, "hello", "there"}, "joe\n", "hello there, joe !\n"},
+		{[]string{"run", script, "hi"}, "bob", "hi, bob !\n"},
+		{[]string{"run", "-", "x"}, `args()[0] + getenv("YEEZY_TEST_VAR")`, "x!\n"},
+		{[]string{"-"}, `readLine()`, "null\n"},
+		{[]string{"eval", "-e", `getenv("YEEZY_UNSET_VAR")`}, "", "null\n"},
+	}
+
+	for _, tt := range tests {
+		stdin = strings.NewReader(tt.stdin)
+		var out, errOut bytes.Buffer
+		code := runCLI(tt.args, &out, &errOut)
+
+		if code != exitOK || out.String() != tt.expectedOut {
+			t.Errorf("%v: expected=%q, got=%q (code=%d, stderr=%q)", tt.args, tt.expectedOut, out.String(), code, errOut.String())
+		}
+	}
+}
+
+func TestHostBuiltinsReadConcurrently(t *testing.T) {
+	lines := []string{}
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprint(i))
+	}
+	builtins := hostBuiltins(nil, strings.NewReader(strings.Join(lines, "\n")))
+
+	var mu sync.Mutex
+	read := map[string]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				line, ok := builtins["readLine"]().(*object.String)
+				if !ok {
+					return
+				}
+				mu.Lock()
+				read[line.Value] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(read) != len(lines) {
+		t.Errorf("expected every line to be read once, got %d of %d lines", len(read), len(lines))
+	}
+
+	errObj, ok := builtins["getenv"](&object.Integer{Value: 1}).(*object.Error)
+	if !ok || errObj.Message != "getenv doesn't support the given argument. got=INTEGER" {
+		t.Errorf("wrong error for getenv(1). got=%+v", errObj)
+	}
+}
+
+func TestFmtCommand(t *testing.T) {
+	dir := t.TempDir()
+	formatted := filepath.Join(dir, "formatted.yz")
+	unformatted := filepath.Join(dir, "lib", "unformatted.yz")
+	if err := os.MkdirAll(filepath.Dir(unformatted), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(formatted, []byte("let x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(unformatted, []byte("let  y=2;y"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runCLI([]string{"fmt", "-check", dir}, &out, &errOut); code != exitUnformatted {
+		t.Errorf("fmt -check: wrong exit code. expected=%d, got=%d (stderr=%q)", exitUnformatted, code, errOut.String())
+	}
+	if out.String() != unformatted+"\n" {
+		t.Errorf("fmt -check: wrong output. expected=%q, got=%q", unformatted+"\n", out.String())
+	}
+
+	out.Reset()
+	if code := runCLI([]string{"fmt", unformatted}, &out, &errOut); code != exitOK || out.String() != "let y = 2\ny\n" {
+		t.Errorf("fmt: wrong result. code=%d, output=%q", code, out.String())
+	}
+
+	if code := runCLI([]string{"fmt", "-w", dir}, &out, &errOut); code != exitOK {
+		t.Errorf("fmt -w: wrong exit code. expected=%d, got=%d (stderr=%q)", exitOK, code, errOut.String())
+	}
+	content, _ := ioutil.ReadFile(unformatted)
+	if string(content) != "let y = 2\ny\n" {
+		t.Errorf("fmt -w: wrong file content. got=%q", content)
+	}
+
+	out.Reset()
+	if code := runCLI([]string{"fmt", "-check", dir}, &out, &errOut); code != exitOK || out.String() != "" {
+		t.Errorf("fmt -check after -w: wrong result. code=%d, output=%q", code, out.String())
+	}
+
+	defer func(original io.Reader) { stdin = original }(stdin)
+	stdin = strings.NewReader("let = 1")
+	if code := runCLI([]string{"fmt"}, &out, &errOut); code != exitParseError {
+		t.Errorf("fmt with parse errors: wrong exit code. expected=%d, got=%d", exitParseError, code)
+	}
+}
+
+func TestTestCommand(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"abs.yz":          "export let abs = func(n) {\n  if (n < 0) {\n    return -n\n  }\n  n\n}",
+		"abs_test.yz":     "import \"abs.yz\"\nlet test_negative = func() { assertEqual(abs.abs(-2), 2) }\nlet test_positive = func() { assertEqual(abs.abs(3), 3) }\nlet test_boolean = func() { assertThrows(func() { abs.abs(true) }) }\nlet test = 1",
+		"unused.yz":       "let x = 1",
+		"broken_test.yz":  "throw \"boom\"",
+		"failing_test.yz": "let test_fails = func() {\n  assert(1 > 2)\n}\nlet test_passes = func() { assert(true) }",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out, errOut bytes.Buffer
+	lcov := filepath.Join(dir, "coverage.lcov")
+	if code := runCLI([]string{"test", "-coverprofile", lcov, dir}, &out, &errOut); code != exitTestsFailed {
+		t.Errorf("wrong exit code. expected=%d, got=%d (stderr=%q)", exitTestsFailed, code, errOut.String())
+	}
+
+	expected := "ok   " + filepath.Join(dir, "abs_test.yz") + "\t3 passed\n" +
+		"FAIL " + filepath.Join(dir, "broken_test.yz") + "\n\tError: boom\n\t\tat broken_test (line 1)\n" +
+		"--- FAIL: test_fails\n\tError: assert failed: false is not truthy\n\t\tat test_fails (line 2)\n" +
+		"FAIL " + filepath.Join(dir, "failing_test.yz") + "\t1 failed, 1 passed\n" +
+		"coverage: 100.0% of statements, 100.0% of branches in " + filepath.Join(dir, "abs.yz") + "\n" +
+		"coverage:   0.0% of statements, 100.0% of branches in " + filepath.Join(dir, "unused.yz") + "\n" +
+		"FAIL: 2 failed, 4 passed\n"
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=%q\ngot=     %q", expected, out.String())
+	}
+
+	if report, err := ioutil.ReadFile(lcov); err != nil || !strings.Contains(string(report), "SF:"+filepath.Join(dir, "abs.yz")+"\n") {
+		t.Errorf("wrong lcov report. got=%q (err=%v)", report, err)
+	}
+
+	out.Reset()
+	absTest := filepath.Join(dir, "abs_test.yz")
+	expected = "--- ok: test_negative\n--- ok: test_positive\n--- ok: test_boolean\nok   " + absTest + "\t3 passed\nok: 3 passed\n"
+	if code := runCLI([]string{"test", "-v", absTest}, &out, &errOut); code != exitOK || out.String() != expected {
+		t.Errorf("test -v: wrong result. code=%d\nexpected=%q\ngot=     %q", code, expected, out.String())
+	}
+}
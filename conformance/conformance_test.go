@@ -0,0 +1,169 @@
+package conformance
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from what the first engine does")
+
+// outcome is a type for representing what a program did, which is compared with its golden files.
+type outcome struct {
+	out    string // what the program printed
+	result string // the value of the program
+	err    string // the parse errors or the error that stopped the program
+}
+
+// engine is a type for representing a way of evaluating yeezy programs.
+type engine struct {
+	name string
+	run  func(filePath, src string) outcome
+}
+
+// engines are all the ways yeezy programs can be evaluated, they must do the same with every program of the suite.
+var engines = []engine{
+	{"eval", evalProgram},
+}
+
+// evalProgram evaluates a program with the tree-walking interpreter, the print built-in prints to the outcome.
+func evalProgram(filePath, src string) outcome {
+	var o outcome
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		o.err = "parse errors:\n\t" + strings.Join(p.Errors, "\n\t") + "\n"
+		return o
+	}
+
+	var out bytes.Buffer
+	interpreter := evaluator.New()
+	interpreter.SetBuiltin("print", func(args ...object.Object) object.Object {
+		for _, arg := range args {
+			if str, ok := arg.(*object.String); ok {
+				fmt.Fprintln(&out, str.Inspect())
+			}
+		}
+		return evaluator.NULL
+	})
+
+	result := interpreter.EvalFile(filePath, program, object.NewEnvironment())
+	o.out = out.String()
+	switch result := result.(type) {
+	case nil:
+	case *object.Error:
+		var b strings.Builder
+		if result.Thrown {
+			b.WriteString("Uncaught ")
+		}
+		b.WriteString(result.Inspect() + "\n")
+		for _, frame := range result.Stack {
+			b.WriteString("\t" + frame + "\n")
+		}
+		o.err = b.String()
+	default:
+		o.result = result.Inspect() + "\n"
+	}
+	return o
+}
+
+// goldenFile is a type for representing a golden file of a program, and the part of the outcome it holds.
+type goldenFile struct {
+	ext   string
+	field func(o *outcome) *string
+}
+
+var goldenFiles = []goldenFile{
+	{".out", func(o *outcome) *string { return &o.out }},
+	{".result", func(o *outcome) *string { return &o.result }},
+	{".err", func(o *outcome) *string { return &o.err }},
+}
+
+func readGolden(t *testing.T, program string) outcome {
+	var expected outcome
+	for _, golden := range goldenFiles {
+		content, err := ioutil.ReadFile(strings.TrimSuffix(program, ".yz") + golden.ext)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		*golden.field(&expected) = string(content)
+	}
+	return expected
+}
+
+func writeGolden(t *testing.T, program string, o outcome) {
+	for _, golden := range goldenFiles {
+		path := strings.TrimSuffix(program, ".yz") + golden.ext
+		content := *golden.field(&o)
+		if content == "" {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	programs, err := filepath.Glob(filepath.Join("testdata", "*.yz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(programs) == 0 {
+		t.Fatal("no programs in testdata")
+	}
+
+	for _, program := range programs {
+		program := program
+		src, err := ioutil.ReadFile(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for idx, e := range engines {
+			e := e
+			t.Run(e.name+"/"+strings.TrimSuffix(filepath.Base(program), ".yz"), func(t *testing.T) {
+				got := e.run(program, string(src))
+				if *update && idx == 0 {
+					writeGolden(t, program, got)
+				}
+
+				expected := readGolden(t, program)
+				compare(t, "output", expected.out, got.out)
+				compare(t, "result", expected.result, got.result)
+				compare(t, "error", expected.err, got.err)
+			})
+		}
+	}
+}
+
+func compare(t *testing.T, what, expected, got string) {
+	if expected != got {
+		t.Errorf("wrong %s.\nexpected:\n%s\ngot:\n%s", what, indent(expected), indent(got))
+	}
+}
+
+func indent(s string) string {
+	if s == "" {
+		return "\t(nothing)"
+	}
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(s, "\n"), "\n") {
+		io.WriteString(&b, "\t"+line)
+	}
+	return b.String()
+}
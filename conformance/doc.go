@@ -0,0 +1,16 @@
+// Package conformance is the suite of yeezy programs that pins down what the language does. It has no code, only the
+// programs and the test that runs them.
+//
+// Every testdata/NAME.yz file is a program, evaluated as the file it is, so it can import the modules in
+// testdata/lib. What it must do is in the golden files next to it:
+//
+//	NAME.out     what the program prints
+//	NAME.result  the value of the program, as the REPL shows it
+//	NAME.err     the parse errors of the program, or the error that stopped it with its stack
+//
+// A missing golden file means the program must not print anything, have a value, or fail. Every engine that can
+// evaluate yeezy programs is run on every program, so that they all agree on the language.
+//
+// `go test ./conformance -update` rewrites the golden files from what the first engine does, to add a program or to
+// change what the language does on purpose.
+package conformance
@@ -0,0 +1,215 @@
+// Package cover records which statements and branches of yeezy programs are evaluated, the way `yeezy test -cover`
+// does.
+//
+// A Coverage is the evaluator.FileHook of the interpreters that run the programs. It is told about every file they
+// evaluate, and counts the statements and the if/else branches of those files as they are evaluated. The counts are
+// kept by position, so that a file that is parsed again by another interpreter adds to the same counts.
+package cover
+
+import (
+	"sort"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/object"
+)
+
+// Statement is a type for representing a statement of a file and the number of times it was evaluated.
+type Statement struct {
+	Line, Column int
+	Hits         int
+}
+
+// Branch is a type for representing a branch of an if expression and the number of times it was taken.
+// Every if expression has 2 branches, the else branch of an if without an else is taken when its condition is false.
+type Branch struct {
+	Line, Column int // the position of the if expression
+	Else         bool
+	Hits         int
+}
+
+// File is a type for representing the coverage of one file.
+type File struct {
+	Path       string
+	Statements []*Statement // sorted by their position
+	Branches   []*Branch    // sorted by their position, the then branch of an if is before its else branch
+}
+
+// position is a type for representing the position of a node in a file.
+type position struct {
+	line, column int
+}
+
+// ifBranches is a type for representing the branches of an if expression.
+type ifBranches struct {
+	then, otherwise *Branch
+	hits            int  // number of times the if was evaluated
+	implicitElse    bool // the if has no else, its else branch is counted from hits
+}
+
+type file struct {
+	path       string
+	statements map[position]*Statement
+	ifs        map[position]*ifBranches
+}
+
+// Coverage is a type for representing the coverage of the files evaluated by a number of interpreters.
+type Coverage struct {
+	// Skip, if it is set, tells which files are not covered, like the files of the tests themselves.
+	Skip func(path string) bool
+
+	files      map[string]*file
+	statements map[ast.Node]*Statement  // by the nodes of the programs that were parsed
+	ifs        map[ast.Node]*ifBranches // by the if expression nodes
+	blocks     map[ast.Node]*ifBranches // by the consequence and alternative nodes of if expressions
+	elses      map[ast.Node]bool        // the alternative nodes
+}
+
+// New returns an empty coverage, which is set as the Hook of the interpreters whose evaluation it records.
+func New() *Coverage {
+	return &Coverage{
+		files:      make(map[string]*file),
+		statements: make(map[ast.Node]*Statement),
+		ifs:        make(map[ast.Node]*ifBranches),
+		blocks:     make(map[ast.Node]*ifBranches),
+		elses:      make(map[ast.Node]bool),
+	}
+}
+
+// BeforeFile registers the statements and branches of a file, AddFile does it for files that may not be evaluated.
+func (c *Coverage) BeforeFile(path string, program *ast.Program) {
+	c.AddFile(path, program)
+}
+
+// AddFile registers the statements and branches of the program parsed from the file at path, so that the file is
+// covered even if it is never evaluated.
+func (c *Coverage) AddFile(path string, program *ast.Program) {
+	if c.Skip != nil && c.Skip(path) {
+		return
+	}
+
+	f, ok := c.files[path]
+	if !ok {
+		f = &file{path: path, statements: make(map[position]*Statement), ifs: make(map[position]*ifBranches)}
+		c.files[path] = f
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.BlockStatementNode:
+		case ast.StatementNode:
+			line, column := node.Position()
+			pos := position{line, column}
+			if _, ok := f.statements[pos]; !ok {
+				f.statements[pos] = &Statement{Line: line, Column: column}
+			}
+			c.statements[node] = f.statements[pos]
+
+		case *ast.IfExpressionNode:
+			line, column := node.Position()
+			pos := position{line, column}
+			if _, ok := f.ifs[pos]; !ok {
+				f.ifs[pos] = &ifBranches{
+					then:         &Branch{Line: line, Column: column},
+					otherwise:    &Branch{Line: line, Column: column, Else: true},
+					implicitElse: node.Alternative == nil,
+				}
+			}
+			c.ifs[node] = f.ifs[pos]
+			c.blocks[node.Consequence] = f.ifs[pos]
+			if node.Alternative != nil {
+				c.blocks[node.Alternative] = f.ifs[pos]
+				c.elses[node.Alternative] = true
+			}
+		}
+		return true
+	})
+}
+
+// BeforeNode counts the statements, the if expressions and the branches as they are evaluated.
+func (c *Coverage) BeforeNode(node ast.Node, env *object.Environment) {
+	if stmt, ok := c.statements[node]; ok {
+		stmt.Hits++
+	}
+	if branches, ok := c.ifs[node]; ok {
+		branches.hits++
+	}
+	if branches, ok := c.blocks[node]; ok {
+		if c.elses[node] {
+			branches.otherwise.Hits++
+		} else {
+			branches.then.Hits++
+		}
+	}
+}
+
+// Files returns the coverage of the files, sorted by their paths.
+func (c *Coverage) Files() []*File {
+	paths := make([]string, 0, len(c.files))
+	for path := range c.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	files := make([]*File, 0, len(paths))
+	for _, path := range paths {
+		f := c.files[path]
+		covered := &File{Path: path}
+
+		for _, stmt := range f.statements {
+			covered.Statements = append(covered.Statements, stmt)
+		}
+		sort.Slice(covered.Statements, func(i, j int) bool {
+			return before(covered.Statements[i].Line, covered.Statements[i].Column, covered.Statements[j].Line, covered.Statements[j].Column)
+		})
+
+		for _, branches := range f.ifs {
+			if branches.implicitElse {
+				// The condition was false every time the then branch was not taken, unless evaluating it failed.
+				branches.otherwise.Hits = branches.hits - branches.then.Hits
+			}
+			covered.Branches = append(covered.Branches, branches.then, branches.otherwise)
+		}
+		sort.Slice(covered.Branches, func(i, j int) bool {
+			bi, bj := covered.Branches[i], covered.Branches[j]
+			if bi.Line == bj.Line && bi.Column == bj.Column {
+				return !bi.Else
+			}
+			return before(bi.Line, bi.Column, bj.Line, bj.Column)
+		})
+
+		files = append(files, covered)
+	}
+	return files
+}
+
+func before(line, column, otherLine, otherColumn int) bool {
+	return line < otherLine || line == otherLine && column < otherColumn
+}
+
+// StatementsCovered returns the number of statements of the file that were evaluated, and the number of statements.
+func (f *File) StatementsCovered() (covered, total int) {
+	for _, stmt := range f.Statements {
+		if stmt.Hits > 0 {
+			covered++
+		}
+	}
+	return covered, len(f.Statements)
+}
+
+// BranchesCovered returns the number of branches of the file that were taken, and the number of branches.
+func (f *File) BranchesCovered() (covered, total int) {
+	for _, branch := range f.Branches {
+		if branch.Hits > 0 {
+			covered++
+		}
+	}
+	return covered, len(f.Branches)
+}
+
+// Percent returns covered as a percentage of total, a file without anything to cover is fully covered.
+func Percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(total)
+}
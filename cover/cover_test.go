@@ -0,0 +1,147 @@
+package cover
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+)
+
+const sign = `export let sign = func(n) {
+  if (n < 0) {
+    return -1
+  } else {
+    if (n == 0) {
+      return 0
+    }
+  }
+  1
+}`
+
+// evalFiles writes the files to a directory, and evaluates every main as main.yz in it with a new interpreter.
+func evalFiles(t *testing.T, coverage *Coverage, files map[string]string, mains ...string) string {
+	dir := t.TempDir()
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, main := range mains {
+		if err := ioutil.WriteFile(filepath.Join(dir, "main.yz"), []byte(main), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		p := parser.New(lexer.New(main))
+		program := p.ParseProgram()
+		if len(p.Errors) != 0 {
+			t.Fatalf("parse errors in %q: %v", main, p.Errors)
+		}
+
+		interpreter := evaluator.New()
+		interpreter.Hook = coverage
+		if result := interpreter.EvalFile(filepath.Join(dir, "main.yz"), program, object.NewEnvironment()); result != nil && result.Type() == object.ERROROBJ {
+			t.Fatalf("%q: %s", main, result.Inspect())
+		}
+	}
+	return dir
+}
+
+func summary(f *File) string {
+	hits := []string{}
+	for _, stmt := range f.Statements {
+		hits = append(hits, fmt.Sprintf("%d:%d=%d", stmt.Line, stmt.Column, stmt.Hits))
+	}
+	for _, branch := range f.Branches {
+		name := "then"
+		if branch.Else {
+			name = "else"
+		}
+		hits = append(hits, fmt.Sprintf("%s@%d=%d", name, branch.Line, branch.Hits))
+	}
+	return strings.Join(hits, " ")
+}
+
+func TestFiles(t *testing.T) {
+	tests := []struct {
+		mains    []string
+		expected string
+	}{
+		{
+			[]string{`import "sign.yz"`},
+			"1:1=1 1:8=1 2:3=0 3:5=0 5:5=0 6:7=0 9:3=0 then@2=0 else@2=0 then@5=0 else@5=0",
+		},
+		{
+			[]string{`import "sign.yz"; sign.sign(5)`},
+			"1:1=1 1:8=1 2:3=1 3:5=0 5:5=1 6:7=0 9:3=1 then@2=0 else@2=1 then@5=0 else@5=1",
+		},
+		{
+			// Every interpreter parses the file again, the counts are added up by position.
+			[]string{`import "sign.yz"; sign.sign(5)`, `import "sign.yz"; sign.sign(-1); sign.sign(0)`},
+			"1:1=2 1:8=2 2:3=3 3:5=1 5:5=2 6:7=1 9:3=1 then@2=1 else@2=2 then@5=1 else@5=1",
+		},
+	}
+
+	for _, tt := range tests {
+		coverage := New()
+		coverage.Skip = func(path string) bool { return filepath.Base(path) == "main.yz" }
+		evalFiles(t, coverage, map[string]string{"sign.yz": sign}, tt.mains...)
+
+		files := coverage.Files()
+		if len(files) != 1 || filepath.Base(files[0].Path) != "sign.yz" {
+			t.Fatalf("%v: wrong files. expected only sign.yz, got=%v", tt.mains, files)
+		}
+		if got := summary(files[0]); got != tt.expected {
+			t.Errorf("%v: wrong coverage.\nexpected=%q\ngot=     %q", tt.mains, tt.expected, got)
+		}
+	}
+}
+
+func TestReports(t *testing.T) {
+	coverage := New()
+	dir := evalFiles(t, coverage, map[string]string{"sign.yz": sign}, `import "sign.yz"; sign.sign(5)`)
+
+	statements, branches := 0, 0
+	for _, f := range coverage.Files() {
+		if filepath.Base(f.Path) == "sign.yz" {
+			covered, total := f.StatementsCovered()
+			statements = int(Percent(covered, total))
+			branches = int(Percent(f.BranchesCovered()))
+		}
+	}
+	if statements != 71 || branches != 50 {
+		t.Errorf("wrong percentages. expected=71%% of statements, 50%% of branches, got=%d%%, %d%%", statements, branches)
+	}
+
+	var lcov bytes.Buffer
+	if err := coverage.WriteLcov(&lcov); err != nil {
+		t.Fatal(err)
+	}
+	expected := "TN:\nSF:" + filepath.Join(dir, "main.yz") + "\nBRF:0\nBRH:0\nDA:1,1\nLF:1\nLH:1\nend_of_record\n" +
+		"TN:\nSF:" + filepath.Join(dir, "sign.yz") + "\nBRDA:2,0,0,0\nBRDA:2,0,1,1\nBRDA:5,1,0,0\nBRDA:5,1,1,1\nBRF:4\nBRH:2\n" +
+		"DA:1,1\nDA:2,1\nDA:3,0\nDA:5,1\nDA:6,0\nDA:9,1\nLF:6\nLH:4\nend_of_record\n"
+	if lcov.String() != expected {
+		t.Errorf("wrong lcov report.\nexpected=%q\ngot=     %q", expected, lcov.String())
+	}
+
+	var html bytes.Buffer
+	if err := coverage.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`<span class="partial"><span class="line">2</span>  if (n &lt; 0) {</span>`,
+		`<span class="uncovered"><span class="line">3</span>    return -1</span>`,
+		`<span class="covered"><span class="line">9</span>  1</span>`,
+	} {
+		if !strings.Contains(html.String(), line) {
+			t.Errorf("the html report is missing %q", line)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cover
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// WriteLcov writes the coverage in the lcov tracefile format, which genhtml and most editors and CI services read.
+func (c *Coverage) WriteLcov(w io.Writer) error {
+	var b strings.Builder
+	for _, f := range c.Files() {
+		fmt.Fprintf(&b, "TN:\nSF:%s\n", f.Path)
+
+		for idx := 0; idx+1 < len(f.Branches); idx += 2 {
+			then, otherwise := f.Branches[idx], f.Branches[idx+1]
+			for branch, br := range []*Branch{then, otherwise} {
+				taken := "-" // the if was never evaluated
+				if then.Hits+otherwise.Hits > 0 {
+					taken = fmt.Sprint(br.Hits)
+				}
+				fmt.Fprintf(&b, "BRDA:%d,%d,%d,%s\n", br.Line, idx/2, branch, taken)
+			}
+		}
+		branchesCovered, branches := f.BranchesCovered()
+		fmt.Fprintf(&b, "BRF:%d\nBRH:%d\n", branches, branchesCovered)
+
+		lines := lineHits(f)
+		linesCovered := 0
+		for _, line := range lines {
+			fmt.Fprintf(&b, "DA:%d,%d\n", line.number, line.hits)
+			if line.hits > 0 {
+				linesCovered++
+			}
+		}
+		fmt.Fprintf(&b, "LF:%d\nLH:%d\nend_of_record\n", len(lines), linesCovered)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// coveredLine is a type for representing a line that statements start on.
+type coveredLine struct {
+	number    int
+	hits      int  // the most times a statement on the line was evaluated
+	uncovered bool // a statement on the line or a branch of an if on it was never evaluated
+}
+
+// lineHits returns the lines statements of a file start on, in order.
+func lineHits(f *File) []*coveredLine {
+	lines := []*coveredLine{}
+	byNumber := make(map[int]*coveredLine)
+	for _, stmt := range f.Statements {
+		line, ok := byNumber[stmt.Line]
+		if !ok {
+			line = &coveredLine{number: stmt.Line}
+			byNumber[stmt.Line] = line
+			lines = append(lines, line)
+		}
+		if stmt.Hits > line.hits {
+			line.hits = stmt.Hits
+		}
+		if stmt.Hits == 0 {
+			line.uncovered = true
+		}
+	}
+
+	for _, branch := range f.Branches {
+		if line, ok := byNumber[branch.Line]; ok && branch.Hits == 0 {
+			line.uncovered = true
+		}
+	}
+	return lines
+}
+
+var htmlReport = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>yeezy coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { line-height: 1.4; }
+.line { color: #999; display: inline-block; text-align: right; width: 4em; margin-right: 1em; }
+.covered { background: #d6f5d6; }
+.partial { background: #fcefc4; }
+.uncovered { background: #f8d0d0; }
+</style>
+</head>
+<body>
+{{range .}}<h2>{{.Path}}: {{printf "%.1f" .Statements}}% of statements, {{printf "%.1f" .Branches}}% of branches</h2>
+<pre>{{range .Lines}}<span class="{{.Class}}"><span class="line">{{.Number}}</span>{{.Text}}</span>
+{{end}}</pre>
+{{end}}</body>
+</html>
+`))
+
+type htmlFile struct {
+	Path                 string
+	Statements, Branches float64
+	Lines                []htmlLine
+}
+
+type htmlLine struct {
+	Number int
+	Text   string
+	Class  string // covered, partial or uncovered, empty for lines without statements
+}
+
+// WriteHTML writes the coverage as a web page that shows the source code of the files, with the lines that were
+// evaluated in green, the lines that were partly evaluated in yellow and the lines that were not evaluated in red.
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	files := []htmlFile{}
+	for _, f := range c.Files() {
+		src, err := ioutil.ReadFile(f.Path)
+		if err != nil {
+			return err
+		}
+
+		classes := make(map[int]string)
+		for _, line := range lineHits(f) {
+			switch {
+			case line.hits == 0:
+				classes[line.number] = "uncovered"
+			case line.uncovered:
+				classes[line.number] = "partial"
+			default:
+				classes[line.number] = "covered"
+			}
+		}
+
+		page := htmlFile{Path: f.Path}
+		page.Statements = Percent(f.StatementsCovered())
+		page.Branches = Percent(f.BranchesCovered())
+		for idx, text := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+			page.Lines = append(page.Lines, htmlLine{Number: idx + 1, Text: text, Class: classes[idx+1]})
+		}
+		files = append(files, page)
+	}
+
+	return htmlReport.Execute(w, files)
+}
@@ -0,0 +1,481 @@
+// Package dap is a Debug Adapter Protocol server for yeezy programs, the way `yeezy dap` runs it.
+//
+// It lets editors launch a program, set breakpoints in it, step through it, and look at its call stack, at the
+// environment chain of each call as scopes, and at the bindings in them as variables. The program runs in its own
+// goroutine, which waits for the editor whenever the debugger pauses it.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/shksa/yeezy/debugger"
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/framing"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+)
+
+// threadID is the ID of the only thread of a program.
+const threadID = 1
+
+// Server is a type for representing a debug adapter talking to one editor, which debugs one program.
+type Server struct {
+	in             *bufio.Reader
+	newInterpreter func(args []string) *evaluator.Interpreter
+
+	outMu sync.Mutex // guards out and seq, as the program's goroutine sends events too
+	out   io.Writer
+	seq   int
+
+	programPath string
+	debugger    *debugger.Debugger
+	configured  bool // the editor has sent its breakpoints
+	started     bool
+	resume      chan debugger.Action
+	done        chan struct{} // closed when the program ends
+
+	pauseMu sync.Mutex      // guards pause and refs, which are set by the program's goroutine
+	pause   *debugger.Pause // the current pause, nil while the program runs
+	refs    []interface{}   // *object.Environment or *object.Array of each variables reference, valid during a pause
+}
+
+// New returns a debug adapter reading messages from in and writing messages to out. newInterpreter returns the
+// interpreter that runs the program with its arguments.
+func New(in io.Reader, out io.Writer, newInterpreter func(args []string) *evaluator.Interpreter) *Server {
+	return &Server{
+		in:             bufio.NewReader(in),
+		out:            out,
+		newInterpreter: newInterpreter,
+		resume:         make(chan debugger.Action),
+		done:           make(chan struct{}),
+	}
+}
+
+// Serve handles requests until the editor sends the disconnect request or closes the connection.
+func (s *Server) Serve() error {
+	defer s.stopProgram()
+
+	for {
+		body, err := framing.Read(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("invalid message: %s", err)
+		}
+
+		result, err := s.handle(req)
+		resp := response{Type: "response", RequestSeq: req.Seq, Success: err == nil, Command: req.Command, Body: result}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		s.send(&resp)
+
+		s.afterResponse(req.Command, err == nil)
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// send writes a response or an event with the next sequence number.
+func (s *Server) send(msg interface{}) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	s.seq++
+	switch msg := msg.(type) {
+	case *response:
+		msg.Seq = s.seq
+	case *event:
+		msg.Seq = s.seq
+	}
+	framing.Write(s.out, msg)
+}
+
+func (s *Server) sendEvent(name string, body interface{}) {
+	s.send(&event{Type: "event", Event: name, Body: body})
+}
+
+func (s *Server) handle(req request) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		return map[string]bool{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+
+	case "launch":
+		var args launchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args)
+
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.setBreakpoints(args), nil
+
+	case "configurationDone":
+		s.configured = true
+		return nil, nil
+
+	case "threads":
+		return map[string]interface{}{"threads": []map[string]interface{}{{"id": threadID, "name": "main"}}}, nil
+
+	case "stackTrace":
+		return s.stackTrace()
+
+	case "scopes":
+		var args frameArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.scopes(args.FrameID)
+
+	case "variables":
+		var args variablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args.VariablesReference)
+
+	case "evaluate":
+		var args evaluateArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.evaluate(args)
+
+	case "continue":
+		return map[string]bool{"allThreadsContinued": true}, s.checkPaused()
+
+	case "next", "stepIn", "stepOut":
+		return nil, s.checkPaused()
+
+	case "pause":
+		if s.debugger != nil {
+			s.debugger.Pause()
+		}
+		return nil, nil
+
+	case "terminate", "disconnect":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("request not supported: %s", req.Command)
+}
+
+// afterResponse does what a request asks for once the editor has got the response, as the events that follow have to
+// come after it.
+func (s *Server) afterResponse(command string, success bool) {
+	if !success {
+		return
+	}
+
+	switch command {
+	case "launch":
+		s.sendEvent("initialized", nil)
+	case "configurationDone":
+		s.startProgram()
+	case "continue":
+		s.resume <- debugger.Continue
+	case "next":
+		s.resume <- debugger.StepOver
+	case "stepIn":
+		s.resume <- debugger.StepIn
+	case "stepOut":
+		s.resume <- debugger.StepOut
+	case "terminate":
+		s.stopProgram()
+	}
+}
+
+func (s *Server) launch(args launchArguments) error {
+	if s.debugger != nil {
+		return fmt.Errorf("a program is already launched")
+	}
+
+	programPath, err := filepath.Abs(args.Program)
+	if err != nil {
+		return err
+	}
+
+	src, err := ioutil.ReadFile(programPath)
+	if err != nil {
+		return err
+	}
+
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		return fmt.Errorf("parse errors in %s: %v", args.Program, p.Errors)
+	}
+
+	interpreter := s.newInterpreter(args.Args)
+	interpreter.SetBuiltin("print", func(args ...object.Object) object.Object {
+		for _, arg := range args {
+			if str, ok := arg.(*object.String); ok {
+				s.sendEvent("output", map[string]string{"category": "stdout", "output": str.Inspect() + "\n"})
+			}
+		}
+		return evaluator.NULL
+	})
+
+	s.programPath = programPath
+	s.debugger = debugger.New(interpreter, program)
+	s.debugger.StopOnEntry = args.StopOnEntry
+	s.debugger.OnPause = s.onPause
+	return nil
+}
+
+func (s *Server) setBreakpoints(args setBreakpointsArguments) map[string]interface{} {
+	breakpoints := []Breakpoint{}
+	path, _ := filepath.Abs(args.Source.Path)
+
+	for _, line := range s.debuggerBreakpoints(path) {
+		s.debugger.ClearBreakpoint(line)
+	}
+
+	for _, bp := range args.Breakpoints {
+		breakpoint := Breakpoint{Line: bp.Line}
+		switch {
+		case s.debugger == nil:
+			breakpoint.Message = "the program is not launched yet"
+		case path != s.programPath:
+			breakpoint.Message = "breakpoints can only be set in the launched program"
+		case !s.debugger.SetBreakpoint(bp.Line):
+			breakpoint.Message = "no statement starts on this line"
+		default:
+			breakpoint.Verified = true
+		}
+		breakpoints = append(breakpoints, breakpoint)
+	}
+
+	return map[string]interface{}{"breakpoints": breakpoints}
+}
+
+// debuggerBreakpoints returns the breakpoints already set in the file at path.
+func (s *Server) debuggerBreakpoints(path string) []int {
+	if s.debugger == nil || path != s.programPath {
+		return nil
+	}
+	return s.debugger.Breakpoints()
+}
+
+// startProgram runs the launched program in its own goroutine, once the editor has set its breakpoints.
+func (s *Server) startProgram() {
+	if s.debugger == nil || !s.configured || s.started {
+		return
+	}
+	s.started = true
+
+	go func() {
+		defer close(s.done)
+
+		result, quit := s.debugger.Run(s.programPath, object.NewEnvironment())
+		exitCode := 0
+		switch {
+		case quit:
+		case result == nil:
+		case result.Type() == object.ERROROBJ:
+			errObj := result.(*object.Error)
+			output := errObj.Inspect() + "\n"
+			for _, frame := range errObj.Stack {
+				output += "\t" + frame + "\n"
+			}
+			s.sendEvent("output", map[string]string{"category": "stderr", "output": output})
+			exitCode = 1
+		default:
+			s.sendEvent("output", map[string]string{"category": "console", "output": result.Inspect() + "\n"})
+		}
+
+		s.sendEvent("exited", map[string]int{"exitCode": exitCode})
+		s.sendEvent("terminated", nil)
+	}()
+}
+
+// stopProgram stops the program if it is running, and waits for it to end.
+func (s *Server) stopProgram() {
+	if !s.started {
+		return
+	}
+
+	s.debugger.Stop()
+	for {
+		// The program can be paused already, or be about to pause, in which case it has to be told to quit.
+		select {
+		case <-s.done:
+			return
+		case s.resume <- debugger.Quit:
+		}
+	}
+}
+
+// onPause is called in the program's goroutine, it tells the editor that the program stopped and waits for it to
+// resume the program.
+func (s *Server) onPause(pause *debugger.Pause) debugger.Action {
+	s.pauseMu.Lock()
+	s.pause = pause
+	s.refs = nil
+	s.pauseMu.Unlock()
+
+	s.sendEvent("stopped", map[string]interface{}{"reason": pause.Reason, "threadId": threadID, "allThreadsStopped": true})
+	action := <-s.resume
+
+	s.pauseMu.Lock()
+	s.pause = nil
+	s.pauseMu.Unlock()
+	return action
+}
+
+func (s *Server) currentPause() *debugger.Pause {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	return s.pause
+}
+
+func (s *Server) checkPaused() error {
+	if s.currentPause() == nil {
+		return fmt.Errorf("the program is not paused")
+	}
+	return nil
+}
+
+func (s *Server) stackTrace() (interface{}, error) {
+	pause := s.currentPause()
+	if pause == nil {
+		return nil, fmt.Errorf("the program is not paused")
+	}
+
+	frames := []StackFrame{}
+	for idx, frame := range pause.Stack {
+		line := frame.Line
+		if idx == 0 {
+			line = pause.Line
+		}
+		source := Source{Name: filepath.Base(s.programPath), Path: s.programPath}
+		frames = append(frames, StackFrame{ID: frame.ID, Name: frame.Name, Source: source, Line: line, Column: 1})
+	}
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+// frameEnv returns the environment of the statement a frame of the paused program is at, the innermost frame's for 0.
+func (s *Server) frameEnv(frameID int) (*object.Environment, error) {
+	pause := s.currentPause()
+	if pause == nil {
+		return nil, fmt.Errorf("the program is not paused")
+	}
+
+	if frameID == 0 {
+		return pause.Env, nil
+	}
+	for idx, frame := range pause.Stack {
+		if frame.ID == frameID {
+			if idx == 0 {
+				return pause.Env, nil
+			}
+			return frame.Env, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown frame %d", frameID)
+}
+
+// scopes returns the environment chain of a frame, from its innermost environment to the program's environment.
+func (s *Server) scopes(frameID int) (interface{}, error) {
+	env, err := s.frameEnv(frameID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []*object.Environment{}
+	for ; env != nil; env = env.Outer() {
+		chain = append(chain, env)
+	}
+
+	scopes := []Scope{}
+	for idx, env := range chain {
+		name := "Locals"
+		switch {
+		case idx == len(chain)-1:
+			name = "Globals"
+		case idx > 0:
+			name = fmt.Sprintf("Closure #%d", idx)
+		}
+		scopes = append(scopes, Scope{Name: name, VariablesReference: s.addRef(env)})
+	}
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+// addRef returns a variables reference for an environment or an array, which is valid till the program resumes.
+func (s *Server) addRef(value interface{}) int {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	s.refs = append(s.refs, value)
+	return len(s.refs)
+}
+
+func (s *Server) variables(ref int) (interface{}, error) {
+	s.pauseMu.Lock()
+	if ref < 1 || ref > len(s.refs) {
+		s.pauseMu.Unlock()
+		return nil, fmt.Errorf("unknown variables reference %d", ref)
+	}
+	value := s.refs[ref-1]
+	s.pauseMu.Unlock()
+
+	variables := []Variable{}
+	switch value := value.(type) {
+	case *object.Environment:
+		for _, name := range value.Names() {
+			obj, _ := value.Get(name)
+			variables = append(variables, s.variable(name, obj))
+		}
+	case *object.Array:
+		for idx, element := range value.Elements {
+			variables = append(variables, s.variable(fmt.Sprintf("[%d]", idx), element))
+		}
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+func (s *Server) variable(name string, value object.Object) Variable {
+	variable := Variable{Name: name, Value: debugger.Inspect(value), Type: value.Type()}
+	if array, ok := value.(*object.Array); ok && len(array.Elements) > 0 {
+		variable.VariablesReference = s.addRef(array)
+	}
+	return variable
+}
+
+func (s *Server) evaluate(args evaluateArguments) (interface{}, error) {
+	env, err := s.frameEnv(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.debugger.Evaluate(args.Expression, env)
+	if errObj, ok := result.(*object.Error); ok {
+		return nil, fmt.Errorf("%s", errObj.Message)
+	}
+
+	if result == nil {
+		return map[string]interface{}{"result": "", "variablesReference": 0}, nil
+	}
+	return map[string]interface{}{"result": debugger.Inspect(result), "type": result.Type(), "variablesReference": s.variable("", result).VariablesReference}, nil
+}
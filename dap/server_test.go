@@ -0,0 +1,226 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/framing"
+)
+
+const factorial = `let fact = func(n) {
+  if (n < 2) {
+    return 1
+  }
+  let rest = fact(n - 1)
+  n * rest
+}
+let x = fact(3)
+print("done")
+x + 1`
+
+// testClient is an editor talking to a server, which runs in its own goroutine.
+type testClient struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan map[string]interface{}
+	served   chan error
+	seq      int
+}
+
+func newTestClient(t *testing.T) *testClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &testClient{t: t, in: clientOut, messages: make(chan map[string]interface{}, 100), served: make(chan error, 1)}
+
+	go func() {
+		c.served <- New(serverIn, serverOut, func(args []string) *evaluator.Interpreter { return evaluator.New() }).Serve()
+		serverOut.Close()
+	}()
+
+	go func() {
+		defer close(c.messages)
+		reader := bufio.NewReader(clientIn)
+		for {
+			body, err := framing.Read(reader)
+			if err != nil {
+				return
+			}
+			var msg map[string]interface{}
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Errorf("invalid JSON from the server: %s", err)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+
+	return c
+}
+
+// request sends a request, and returns the body of its response after checking whether it succeeded.
+func (c *testClient) request(command, arguments string, success bool) map[string]interface{} {
+	c.seq++
+	msg := fmt.Sprintf(`{"seq":%d,"type":"request","command":%q,"arguments":%s}`, c.seq, command, arguments)
+	fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+
+	resp := c.next("response", command)
+	if resp["success"] != success {
+		c.t.Fatalf("%s: wrong success. expected=%t, got=%v", command, success, resp)
+	}
+	body, _ := resp["body"].(map[string]interface{})
+	return body
+}
+
+// next returns the next response to command or event with that name, skipping the other messages.
+func (c *testClient) next(msgType, name string) map[string]interface{} {
+	for msg := range c.messages {
+		if msg["type"] == msgType && (msg["command"] == name || msg["event"] == name) {
+			return msg
+		}
+	}
+	c.t.Fatalf("the server stopped before sending %s %s", msgType, name)
+	return nil
+}
+
+// stopped waits for the program to pause, and returns the reason and the line it paused at.
+func (c *testClient) stopped() string {
+	reason := c.next("event", "stopped")["body"].(map[string]interface{})["reason"]
+	frames := c.request("stackTrace", `{"threadId":1}`, true)["stackFrames"].([]interface{})
+	top := frames[0].(map[string]interface{})
+	return fmt.Sprintf("%s %s:%v", reason, top["name"], top["line"])
+}
+
+func (c *testClient) disconnect() {
+	c.request("disconnect", `{}`, true)
+	if err := <-c.served; err != nil {
+		c.t.Errorf("Serve returned an error: %s", err)
+	}
+}
+
+func writeProgram(t *testing.T, src string) string {
+	dir, err := ioutil.TempDir("", "dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	programPath := filepath.Join(dir, "main.yz")
+	if err := ioutil.WriteFile(programPath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return programPath
+}
+
+// toJSON returns the JSON of a decoded value, to compare it with the expected JSON.
+func toJSON(t *testing.T, value interface{}) string {
+	out, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestSession(t *testing.T) {
+	programPath := writeProgram(t, factorial)
+	c := newTestClient(t)
+
+	capabilities := c.request("initialize", `{"adapterID":"yeezy"}`, true)
+	if capabilities["supportsConfigurationDoneRequest"] != true {
+		t.Errorf("wrong capabilities: %v", capabilities)
+	}
+
+	c.request("launch", fmt.Sprintf(`{"program":%q}`, programPath), true)
+	c.next("event", "initialized")
+
+	breakpoints := c.request("setBreakpoints", fmt.Sprintf(`{"source":{"path":%q},"breakpoints":[{"line":3},{"line":7}]}`, programPath), true)
+	expected := `[{"line":3,"verified":true},{"line":7,"message":"no statement starts on this line","verified":false}]`
+	if got := toJSON(t, breakpoints["breakpoints"]); got != expected {
+		t.Errorf("wrong breakpoints.\nexpected=%s\ngot=     %s", expected, got)
+	}
+
+	c.request("configurationDone", `{}`, true)
+	if where := c.stopped(); where != "breakpoint fact:3" {
+		t.Errorf("wrong pause. expected=%q, got=%q", "breakpoint fact:3", where)
+	}
+
+	frames := c.request("stackTrace", `{"threadId":1}`, true)["stackFrames"].([]interface{})
+	names := []string{}
+	for _, frame := range frames {
+		frame := frame.(map[string]interface{})
+		names = append(names, fmt.Sprintf("%s:%v", frame["name"], frame["line"]))
+	}
+	if got := fmt.Sprint(names); got != "[fact:3 fact:5 fact:5 main:8]" {
+		t.Errorf("wrong stack frames. got=%s", got)
+	}
+
+	topFrame := frames[0].(map[string]interface{})["id"].(float64)
+	scopes := c.request("scopes", fmt.Sprintf(`{"frameId":%v}`, topFrame), true)["scopes"].([]interface{})
+	if len(scopes) != 2 {
+		t.Fatalf("wrong number of scopes. expected=2, got=%d: %v", len(scopes), scopes)
+	}
+
+	for idx, expected := range []string{
+		`[{"name":"n","type":"INTEGER","value":"1","variablesReference":0}]`,
+		`[{"name":"fact","type":"FUNCTION","value":"func(n)","variablesReference":0}]`,
+	} {
+		scope := scopes[idx].(map[string]interface{})
+		variables := c.request("variables", fmt.Sprintf(`{"variablesReference":%v}`, scope["variablesReference"]), true)
+		if got := toJSON(t, variables["variables"]); got != expected {
+			t.Errorf("scope %s: wrong variables.\nexpected=%s\ngot=     %s", scope["name"], expected, got)
+		}
+	}
+
+	if result := c.request("evaluate", fmt.Sprintf(`{"expression":"n + 1","frameId":%v}`, topFrame), true); result["result"] != "2" {
+		t.Errorf("wrong evaluated value. expected=2, got=%v", result)
+	}
+	c.request("evaluate", `{"expression":"m"}`, false)
+
+	c.request("next", `{"threadId":1}`, true)
+	if where := c.stopped(); where != "step fact:6" {
+		t.Errorf("wrong pause. expected=%q, got=%q", "step fact:6", where)
+	}
+
+	c.request("continue", `{"threadId":1}`, true)
+	for _, expected := range []string{"done\n", "7\n"} {
+		if output := c.next("event", "output")["body"].(map[string]interface{})["output"]; output != expected {
+			t.Errorf("wrong output. expected=%q, got=%q", expected, output)
+		}
+	}
+	if exited := c.next("event", "exited")["body"].(map[string]interface{}); exited["exitCode"] != 0.0 {
+		t.Errorf("wrong exit code. got=%v", exited)
+	}
+	c.next("event", "terminated")
+
+	c.request("continue", `{"threadId":1}`, false)
+	c.disconnect()
+}
+
+func TestDisconnectWhilePaused(t *testing.T) {
+	programPath := writeProgram(t, factorial)
+	c := newTestClient(t)
+
+	c.request("initialize", `{}`, true)
+	c.request("launch", fmt.Sprintf(`{"program":%q,"stopOnEntry":true}`, programPath), true)
+	c.request("configurationDone", `{}`, true)
+	if where := c.stopped(); where != "entry main:1" {
+		t.Errorf("wrong pause. expected=%q, got=%q", "entry main:1", where)
+	}
+
+	c.disconnect()
+}
+
+func TestLaunchErrors(t *testing.T) {
+	c := newTestClient(t)
+
+	c.request("launch", `{"program":"missing.yz"}`, false)
+	c.request("launch", fmt.Sprintf(`{"program":%q}`, writeProgram(t, "let = 1")), false)
+	c.request("frob", `{}`, false)
+	c.disconnect()
+}
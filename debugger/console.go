@@ -0,0 +1,235 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shksa/yeezy/object"
+)
+
+// PROMPT is the prompt of the console while the program is paused.
+const PROMPT = "(yzdb) "
+
+// Console is a type for representing the command line front end of a debugger, which `yeezy debug` uses.
+type Console struct {
+	debugger *Debugger
+	lines    []string // the lines of the source code of the program
+	in       *bufio.Scanner
+	out      io.Writer
+}
+
+// consoleCommand is a type for representing a command of the console.
+type consoleCommand struct {
+	names []string // the full name is first, the others are short forms
+	usage string
+	help  string
+	run   func(c *Console, arg string, pause *Pause) (Action, bool) // false keeps the program paused
+}
+
+var consoleCommands []*consoleCommand
+
+func init() {
+	consoleCommands = []*consoleCommand{
+		{[]string{"break", "b"}, "break <line>", "set a breakpoint on a line", (*Console).setBreakpoint},
+		{[]string{"clear"}, "clear <line>", "remove the breakpoint on a line", (*Console).clearBreakpoint},
+		{[]string{"breakpoints"}, "breakpoints", "list the breakpoints", (*Console).listBreakpoints},
+		{[]string{"continue", "c"}, "continue", "run till the next breakpoint", resume(Continue)},
+		{[]string{"step", "s"}, "step", "go to the next statement, stepping into calls", resume(StepIn)},
+		{[]string{"next", "n"}, "next", "go to the next statement, stepping over calls", resume(StepOver)},
+		{[]string{"out", "o"}, "out", "run till the current function returns", resume(StepOut)},
+		{[]string{"stack", "bt"}, "stack", "print the calls that are being evaluated", (*Console).printStack},
+		{[]string{"env", "e"}, "env", "print the bindings of the environment chain of the current statement", (*Console).printEnv},
+		{[]string{"print", "p"}, "print <expression>", "evaluate an expression in the environment of the current statement", (*Console).evaluate},
+		{[]string{"list", "l"}, "list", "print the source code around the current statement", (*Console).list},
+		{[]string{"help", "h"}, "help", "print this help", (*Console).help},
+		{[]string{"quit", "q"}, "quit", "stop the program", resume(Quit)},
+	}
+}
+
+// NewConsole returns a console that reads commands from in and writes to out whenever the debugger pauses.
+// src is the source code of the program, for listing it.
+func NewConsole(d *Debugger, src string, in io.Reader, out io.Writer) *Console {
+	c := &Console{debugger: d, lines: strings.Split(src, "\n"), in: bufio.NewScanner(in), out: out}
+	d.OnPause = c.pause
+	return c
+}
+
+// pause prints where the program is paused, and runs commands till one of them resumes the program.
+func (c *Console) pause(pause *Pause) Action {
+	where := "in " + pause.Stack[0].Name
+	if pause.Reason == ReasonBreakpoint {
+		where = "at breakpoint, " + where
+	}
+	fmt.Fprintf(c.out, "paused %s, line %d:\n", where, pause.Line)
+	c.printLine(pause.Line, true)
+
+	for {
+		fmt.Fprint(c.out, PROMPT)
+		if !c.in.Scan() {
+			fmt.Fprintln(c.out)
+			return Quit
+		}
+
+		input := strings.TrimSpace(c.in.Text())
+		if input == "" {
+			continue
+		}
+
+		name, arg := input, ""
+		if idx := strings.IndexByte(input, ' '); idx >= 0 {
+			name, arg = input[:idx], strings.TrimSpace(input[idx+1:])
+		}
+
+		cmd := findConsoleCommand(name)
+		if cmd == nil {
+			fmt.Fprintf(c.out, "unknown command %q, type help for the commands\n", name)
+			continue
+		}
+
+		if action, resumes := cmd.run(c, arg, pause); resumes {
+			return action
+		}
+	}
+}
+
+func findConsoleCommand(name string) *consoleCommand {
+	for _, cmd := range consoleCommands {
+		for _, cmdName := range cmd.names {
+			if cmdName == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+func resume(action Action) func(c *Console, arg string, pause *Pause) (Action, bool) {
+	return func(c *Console, arg string, pause *Pause) (Action, bool) {
+		return action, true
+	}
+}
+
+func (c *Console) printLine(line int, current bool) {
+	if line < 1 || line > len(c.lines) {
+		return
+	}
+
+	marker := "  "
+	if current {
+		marker = "=>"
+	}
+	if c.debugger.HasBreakpoint(line) {
+		marker = marker[:1] + "*"
+	}
+	fmt.Fprintf(c.out, "%s %4d  %s\n", marker, line, c.lines[line-1])
+}
+
+func parseLine(arg string) (int, error) {
+	line, err := strconv.Atoi(arg)
+	if err != nil || line < 1 {
+		return 0, fmt.Errorf("invalid line %q", arg)
+	}
+	return line, nil
+}
+
+func (c *Console) setBreakpoint(arg string, pause *Pause) (Action, bool) {
+	line, err := parseLine(arg)
+	if err != nil {
+		fmt.Fprintln(c.out, err)
+		return Continue, false
+	}
+
+	if !c.debugger.SetBreakpoint(line) {
+		fmt.Fprintf(c.out, "no statement starts on line %d\n", line)
+		return Continue, false
+	}
+	fmt.Fprintf(c.out, "breakpoint set on line %d\n", line)
+	return Continue, false
+}
+
+func (c *Console) clearBreakpoint(arg string, pause *Pause) (Action, bool) {
+	line, err := parseLine(arg)
+	if err != nil {
+		fmt.Fprintln(c.out, err)
+		return Continue, false
+	}
+
+	c.debugger.ClearBreakpoint(line)
+	fmt.Fprintf(c.out, "breakpoint cleared on line %d\n", line)
+	return Continue, false
+}
+
+func (c *Console) listBreakpoints(arg string, pause *Pause) (Action, bool) {
+	lines := c.debugger.Breakpoints()
+	if len(lines) == 0 {
+		fmt.Fprintln(c.out, "no breakpoints")
+	}
+	for _, line := range lines {
+		c.printLine(line, false)
+	}
+	return Continue, false
+}
+
+func (c *Console) printStack(arg string, pause *Pause) (Action, bool) {
+	for idx, frame := range pause.Stack {
+		fmt.Fprintf(c.out, "#%d %s (line %d)\n", idx, frame.Name, frame.Line)
+	}
+	return Continue, false
+}
+
+// printEnv prints the bindings of every environment of the chain, the innermost one first.
+func (c *Console) printEnv(arg string, pause *Pause) (Action, bool) {
+	for depth, env := 0, pause.Env; env != nil; depth, env = depth+1, env.Outer() {
+		fmt.Fprintf(c.out, "env #%d:\n", depth)
+		for _, name := range env.Names() {
+			value, _ := env.Get(name)
+			fmt.Fprintf(c.out, "  %s = %s\n", name, Inspect(value))
+		}
+	}
+	return Continue, false
+}
+
+func (c *Console) evaluate(arg string, pause *Pause) (Action, bool) {
+	if arg == "" {
+		fmt.Fprintln(c.out, "usage: print <expression>")
+		return Continue, false
+	}
+
+	result := c.debugger.Evaluate(arg, pause.Env)
+	if result != nil {
+		fmt.Fprintln(c.out, Inspect(result))
+	}
+	return Continue, false
+}
+
+func (c *Console) list(arg string, pause *Pause) (Action, bool) {
+	for line := pause.Line - 3; line <= pause.Line+3; line++ {
+		c.printLine(line, line == pause.Line)
+	}
+	return Continue, false
+}
+
+func (c *Console) help(arg string, pause *Pause) (Action, bool) {
+	for _, cmd := range consoleCommands {
+		fmt.Fprintf(c.out, "  %-20s %s\n", cmd.usage, cmd.help)
+		if len(cmd.names) > 1 {
+			fmt.Fprintf(c.out, "  %-20s %s\n", "", "short form: "+strings.Join(cmd.names[1:], ", "))
+		}
+	}
+	return Continue, false
+}
+
+// Inspect returns a value in string format for showing it in a debugger, functions are shortened to their parameters.
+func Inspect(value object.Object) string {
+	if fn, ok := value.(*object.Function); ok {
+		params := []string{}
+		for _, param := range fn.Parameters {
+			params = append(params, param.Name)
+		}
+		return "func(" + strings.Join(params, ", ") + ")"
+	}
+	return value.Inspect()
+}
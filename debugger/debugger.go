@@ -0,0 +1,252 @@
+// Package debugger pauses yeezy programs at breakpoints and steps through them, the way `yeezy debug` and
+// `yeezy dap` do.
+//
+// A Debugger is the evaluator.Hook of the interpreter that runs the program. It pauses the program before statements,
+// and hands the pause to a front end, which decides how the program goes on.
+package debugger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+)
+
+// Action is a type for representing how a paused program goes on.
+type Action int
+
+// The actions a front end can give when the program is paused.
+const (
+	Continue Action = iota // run till a breakpoint
+	StepIn                 // pause at the next statement, which can be in a function the current statement calls
+	StepOver               // pause at the next statement of the current function, or of its caller once it returns
+	StepOut                // pause at the next statement of the caller of the current function
+	Quit                   // stop the program
+)
+
+// The reasons a program is paused for.
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+)
+
+// Pause is a type for representing a program paused before a statement.
+type Pause struct {
+	Reason string
+	Node   ast.StatementNode
+	Line   int
+	Env    *object.Environment    // the environment the statement is evaluated in
+	Stack  []evaluator.StackFrame // the innermost call is first
+}
+
+// Debugger is a type for representing the debugging of one program.
+type Debugger struct {
+	// OnPause is called when the program pauses, and returns how the program goes on.
+	OnPause func(pause *Pause) Action
+	// StopOnEntry pauses the program before its first statement.
+	StopOnEntry bool
+
+	interpreter *evaluator.Interpreter
+	program     *ast.Program
+	statements  map[ast.Node]bool // the statements of the program, breakpoints are not hit in imported files
+	lines       map[int]bool      // the lines statements of the program start on
+
+	mu          sync.Mutex // guards breakpoints, which front ends can change while the program runs
+	breakpoints map[int]bool
+
+	action     Action
+	stepDepth  int         // how many calls deep the program was when the last step started
+	frameLines []frameLine // the line each call is at, the outermost one is first
+	evaluating bool        // an expression is evaluated for the front end, which does not pause
+	request    int32       // set from other goroutines by Pause and Stop, read with atomic
+}
+
+// The requests other goroutines can make while the program runs.
+const (
+	noRequest int32 = iota
+	pauseRequest
+	stopRequest
+)
+
+type frameLine struct {
+	frameID int
+	line    int
+}
+
+// quitProgram is the panic that unwinds the interpreter when the front end quits, Run recovers it.
+type quitProgram struct{}
+
+// New returns a debugger for a program, which becomes the hook of the interpreter that runs it.
+func New(interpreter *evaluator.Interpreter, program *ast.Program) *Debugger {
+	d := &Debugger{
+		interpreter: interpreter,
+		program:     program,
+		statements:  make(map[ast.Node]bool),
+		lines:       make(map[int]bool),
+		breakpoints: make(map[int]bool),
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if stmt, ok := node.(ast.StatementNode); ok && isPausable(stmt) {
+			d.statements[stmt] = true
+			line, _ := stmt.Position()
+			d.lines[line] = true
+		}
+		return true
+	})
+
+	interpreter.Hook = d
+	return d
+}
+
+// isPausable tells whether the program can pause before a statement, blocks are not paused at as the statements in
+// them are.
+func isPausable(stmt ast.StatementNode) bool {
+	_, isBlock := stmt.(*ast.BlockStatementNode)
+	return !isBlock
+}
+
+// SetBreakpoint sets a breakpoint on a line, and returns false if no statement starts on that line.
+func (d *Debugger) SetBreakpoint(line int) bool {
+	if !d.lines[line] {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+	return true
+}
+
+// ClearBreakpoint removes the breakpoint on a line.
+func (d *Debugger) ClearBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints, line)
+}
+
+// HasBreakpoint tells whether there is a breakpoint on a line.
+func (d *Debugger) HasBreakpoint(line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.breakpoints[line]
+}
+
+// Breakpoints returns the sorted lines that have breakpoints.
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Pause makes the running program pause before its next statement. It can be called from any goroutine.
+func (d *Debugger) Pause() {
+	atomic.CompareAndSwapInt32(&d.request, noRequest, pauseRequest)
+}
+
+// Stop makes the running program stop before its next statement, as if OnPause returned Quit. It can be called from
+// any goroutine.
+func (d *Debugger) Stop() {
+	atomic.StoreInt32(&d.request, stopRequest)
+}
+
+// Run evaluates the program in env, pausing it as the breakpoints and OnPause tell. filePath is the file the program
+// was read from, imports are resolved relative to it, it is empty for source code that was not read from a file.
+// quit is true if the program was stopped by the Quit action, in which case result is nil.
+func (d *Debugger) Run(filePath string, env *object.Environment) (result object.Object, quit bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(quitProgram); !ok {
+				panic(r)
+			}
+			result, quit = nil, true
+		}
+	}()
+
+	d.action = Continue
+	if d.StopOnEntry {
+		d.action = StepIn
+	}
+	d.frameLines = nil
+
+	if filePath != "" {
+		return d.interpreter.EvalFile(filePath, d.program, env), false
+	}
+	return d.interpreter.Eval(d.program, env), false
+}
+
+// Evaluate evaluates source code in an environment of the paused program, without pausing at breakpoints in it.
+func (d *Debugger) Evaluate(src string, env *object.Environment) object.Object {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		return &object.Error{Message: "parse errors: " + strings.Join(p.Errors, "; ")}
+	}
+
+	d.evaluating = true
+	defer func() { d.evaluating = false }()
+
+	return d.interpreter.Eval(program, env)
+}
+
+// BeforeNode pauses the program before a statement if there is a breakpoint on its line or a step ends at it.
+func (d *Debugger) BeforeNode(node ast.Node, env *object.Environment) {
+	stmt, ok := node.(ast.StatementNode)
+	if d.evaluating || !ok || !isPausable(stmt) {
+		return
+	}
+
+	stack := d.interpreter.CallStack()
+	depth := len(stack)
+	line, _ := stmt.Position()
+
+	// A breakpoint is hit when a call gets to its line, not again for every statement on the line.
+	if len(d.frameLines) > depth {
+		d.frameLines = d.frameLines[:depth]
+	}
+	for len(d.frameLines) < depth {
+		d.frameLines = append(d.frameLines, frameLine{})
+	}
+	current := &d.frameLines[depth-1]
+	if current.frameID != stack[0].ID {
+		*current = frameLine{frameID: stack[0].ID}
+	}
+	entersLine := current.line != line
+	current.line = line
+
+	reason := ""
+	switch request := atomic.SwapInt32(&d.request, noRequest); {
+	case request == stopRequest:
+		panic(quitProgram{})
+	case request == pauseRequest:
+		reason = ReasonPause
+	case d.action == StepIn, d.action == StepOver && depth <= d.stepDepth, d.action == StepOut && depth < d.stepDepth:
+		reason = ReasonStep
+		if d.StopOnEntry && d.stepDepth == 0 {
+			reason = ReasonEntry
+		}
+	case entersLine && d.statements[stmt] && d.HasBreakpoint(line):
+		reason = ReasonBreakpoint
+	default:
+		return
+	}
+
+	action := d.OnPause(&Pause{Reason: reason, Node: stmt, Line: line, Env: env, Stack: stack})
+	if action == Quit {
+		panic(quitProgram{})
+	}
+	d.action = action
+	d.stepDepth = depth
+}
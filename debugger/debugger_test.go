@@ -0,0 +1,157 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+)
+
+const factorial = `let fact = func(n) {
+  if (n < 2) {
+    return 1
+  }
+  let rest = fact(n - 1)
+  n * rest
+}
+let x = fact(3)
+x + 1`
+
+func newTestDebugger(t *testing.T, input string) *Debugger {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		t.Fatalf("parse errors in %q: %v", input, p.Errors)
+	}
+	return New(evaluator.New(), program)
+}
+
+// pauseLog is a front end that resumes the program with the given actions, and records where it paused.
+type pauseLog struct {
+	actions []Action
+	pauses  []string
+}
+
+func (log *pauseLog) onPause(pause *Pause) Action {
+	log.pauses = append(log.pauses, fmt.Sprintf("%s %s:%d depth %d", pause.Reason, pause.Stack[0].Name, pause.Line, len(pause.Stack)))
+	if len(log.actions) == 0 {
+		return Continue
+	}
+	action := log.actions[0]
+	log.actions = log.actions[1:]
+	return action
+}
+
+func TestStepping(t *testing.T) {
+	tests := []struct {
+		breakpoints []int
+		stopOnEntry bool
+		actions     []Action
+		expected    []string
+	}{
+		{nil, false, nil, []string{}},
+		{[]int{3}, false, nil, []string{"breakpoint fact:3 depth 4"}},
+		{[]int{5}, false, nil, []string{"breakpoint fact:5 depth 2", "breakpoint fact:5 depth 3"}},
+		{nil, true, []Action{StepIn, StepIn, StepIn}, []string{"entry <program>:1 depth 1", "step <program>:8 depth 1", "step fact:2 depth 2", "step fact:5 depth 2"}},
+		{nil, true, []Action{StepOver, StepOver}, []string{"entry <program>:1 depth 1", "step <program>:8 depth 1", "step <program>:9 depth 1"}},
+		{[]int{3}, false, []Action{StepOver, StepOut, StepOut}, []string{"breakpoint fact:3 depth 4", "step fact:6 depth 3", "step fact:6 depth 2", "step <program>:9 depth 1"}},
+		{[]int{3, 6}, false, []Action{Continue, Continue}, []string{"breakpoint fact:3 depth 4", "breakpoint fact:6 depth 3", "breakpoint fact:6 depth 2"}},
+	}
+
+	for _, tt := range tests {
+		d := newTestDebugger(t, factorial)
+		for _, line := range tt.breakpoints {
+			if !d.SetBreakpoint(line) {
+				t.Fatalf("SetBreakpoint(%d) returned false", line)
+			}
+		}
+		log := &pauseLog{actions: tt.actions, pauses: []string{}}
+		d.OnPause = log.onPause
+		d.StopOnEntry = tt.stopOnEntry
+
+		result, quit := d.Run("", object.NewEnvironment())
+		if quit || result.Inspect() != "7" {
+			t.Errorf("wrong result. expected=7, got=%v (quit=%t)", result, quit)
+		}
+
+		if strings.Join(log.pauses, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("breakpoints %v, actions %v: wrong pauses.\nexpected=%q\ngot=     %q", tt.breakpoints, tt.actions, tt.expected, log.pauses)
+		}
+	}
+}
+
+func TestSetBreakpoint(t *testing.T) {
+	d := newTestDebugger(t, factorial)
+
+	if d.SetBreakpoint(7) {
+		t.Errorf("SetBreakpoint(7) returned true for a line without a statement")
+	}
+	d.SetBreakpoint(6)
+	d.SetBreakpoint(2)
+	d.ClearBreakpoint(6)
+
+	if breakpoints := d.Breakpoints(); len(breakpoints) != 1 || breakpoints[0] != 2 {
+		t.Errorf("wrong breakpoints. expected=[2], got=%v", breakpoints)
+	}
+}
+
+func TestQuitAndEvaluate(t *testing.T) {
+	d := newTestDebugger(t, factorial)
+	d.SetBreakpoint(3)
+
+	evaluated := []string{}
+	d.OnPause = func(pause *Pause) Action {
+		evaluated = append(evaluated, d.Evaluate("n * 10", pause.Env).Inspect(), d.Evaluate("fact(2)", pause.Env).Inspect())
+		return Quit
+	}
+
+	result, quit := d.Run("", object.NewEnvironment())
+	if !quit || result != nil {
+		t.Errorf("the program was not stopped. result=%v, quit=%t", result, quit)
+	}
+
+	if strings.Join(evaluated, " ") != "10 2" {
+		t.Errorf("wrong evaluated values. expected=[10 2], got=%v", evaluated)
+	}
+}
+
+func TestConsole(t *testing.T) {
+	d := newTestDebugger(t, factorial)
+	d.StopOnEntry = true
+	commands := "break 3\nbreak 7\ncontinue\nstack\nenv\nprint n + 1\nprint )\nfrob\nnext\nquit\n"
+	var out bytes.Buffer
+	NewConsole(d, factorial, strings.NewReader(commands), &out)
+
+	if _, quit := d.Run("", object.NewEnvironment()); !quit {
+		t.Errorf("the program was not stopped by quit")
+	}
+
+	expected := `paused in <program>, line 1:
+=>    1  let fact = func(n) {
+(yzdb) breakpoint set on line 3
+(yzdb) no statement starts on line 7
+(yzdb) paused at breakpoint, in fact, line 3:
+=*    3      return 1
+(yzdb) #0 fact (line 3)
+#1 fact (line 5)
+#2 fact (line 5)
+#3 <program> (line 8)
+(yzdb) env #0:
+  n = 1
+env #1:
+  fact = func(n)
+(yzdb) 2
+(yzdb) Error: parse errors: No prefix parse function found for ) token
+(yzdb) unknown command "frob", type help for the commands
+(yzdb) paused in fact, line 6:
+=>    6    n * rest
+(yzdb) `
+	if out.String() != expected {
+		t.Errorf("wrong console output.\nexpected=%q\ngot=     %q", expected, out.String())
+	}
+}
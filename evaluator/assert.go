@@ -0,0 +1,184 @@
+package evaluator
+
+import (
+	"github.com/shksa/yeezy/object"
+)
+
+/* Assertions
+- assert(condition, message?), assertEqual(actual, expected, message?) and assertThrows(fn, message?) are the built-ins
+	tests written in yeezy check things with, `yeezy test` runs the test_* functions that use them.
+- A failed assertion is an error, so it stops the test like any other error, and it can be caught with try/catch.
+- assertEqual compares values by what they hold, not by identity, so [1, [2]] equals another [1, [2]].
+- assertThrows calls fn without arguments, and returns the error it raised as an error value. It fails if fn returned
+	normally, or if message is given and the error has another message.
+- The assertions are bound to an interpreter, since assertThrows calls yeezy functions, so New adds them to every
+	interpreter instead of keeping them in defaultBuiltins.
+*/
+
+func (in *Interpreter) assertBuiltins() map[string]object.BuiltInFunction {
+	return map[string]object.BuiltInFunction{
+		"assert": func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("Wrong number of arguments. want=1 or 2, got=%d", len(args))
+			}
+
+			if !isTruthy(args[0]) {
+				return assertionError(args[1:], "assert failed: %s is not truthy", args[0].Inspect())
+			}
+			return NULL
+		},
+		"assertEqual": func(args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("Wrong number of arguments. want=2 or 3, got=%d", len(args))
+			}
+
+			if !objectsEqual(args[0], args[1]) {
+				return assertionError(args[2:], "assertEqual failed: expected %s, got %s", args[1].Inspect(), args[0].Inspect())
+			}
+			return NULL
+		},
+		"assertThrows": func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("Wrong number of arguments. want=1 or 2, got=%d", len(args))
+			}
+
+			result := in.Call(args[0])
+			errObj, ok := result.(*object.Error)
+			if !ok {
+				return newError("assertThrows failed: the function returned %s instead of throwing", inspectResult(result))
+			}
+
+			if len(args) == 2 {
+				msg, ok := args[1].(*object.String)
+				if !ok {
+					return newError("assertThrows doesn't support the given message. got=%s", args[1].Type())
+				}
+				if errObj.Message != msg.Value {
+					return newError("assertThrows failed: expected the error %q, got %q", msg.Value, errObj.Message)
+				}
+			}
+			return &object.ErrorValue{Message: errObj.Message, Stack: errObj.Stack}
+		},
+	}
+}
+
+// assertionError returns the error of a failed assertion, with the message given to the assertion if there is one.
+func assertionError(message []object.Object, format string, a ...interface{}) *object.Error {
+	if len(message) == 1 {
+		if msg, ok := message[0].(*object.String); ok {
+			return newError("%s", msg.Value)
+		}
+		return newError("%s", message[0].Inspect())
+	}
+	return newError(format, a...)
+}
+
+func inspectResult(result object.Object) string {
+	if result == nil {
+		return "nothing"
+	}
+	return result.Inspect()
+}
+
+// objectsEqual tells whether 2 values hold the same thing. Arrays, structs, hashes and error values are compared by
+// their contents, functions, modules and the other values that have an identity are only equal to themselves.
+func objectsEqual(a, b object.Object) bool {
+	return valuesEqual(a, b, make(map[[2]object.Object]bool))
+}
+
+// valuesEqual compares 2 values like objectsEqual, comparing holds the pairs of arrays, structs and hashes that are
+// being compared around them, which are taken to be equal so that values that contain themselves can be compared.
+func valuesEqual(a, b object.Object, comparing map[[2]object.Object]bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *object.Integer:
+		return a.Value == b.(*object.Integer).Value
+
+	case *object.Boolean:
+		return a.Value == b.(*object.Boolean).Value
+
+	case *object.String:
+		return a.Value == b.(*object.String).Value
+
+	case *object.Null:
+		return true
+
+	case *object.Array:
+		other := b.(*object.Array)
+		if len(a.Elements) != len(other.Elements) {
+			return false
+		}
+		pair := [2]object.Object{a, other}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		defer delete(comparing, pair)
+		for idx := range a.Elements {
+			if !valuesEqual(a.Elements[idx], other.Elements[idx], comparing) {
+				return false
+			}
+		}
+		return true
+
+	case *object.Struct:
+		other := b.(*object.Struct)
+		if a.StructType != other.StructType {
+			return false
+		}
+		pair := [2]object.Object{a, other}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		defer delete(comparing, pair)
+		for idx := range a.Values {
+			if !valuesEqual(a.Values[idx], other.Values[idx], comparing) {
+				return false
+			}
+		}
+		return true
+
+	case *object.Hash:
+		other := b.(*object.Hash)
+		if len(a.Keys) != len(other.Keys) {
+			return false
+		}
+		pair := [2]object.Object{a, other}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		defer delete(comparing, pair)
+		for _, key := range a.Keys {
+			otherPair, ok := other.Pairs[key]
+			if !ok || !valuesEqual(a.Pairs[key].Value, otherPair.Value, comparing) {
+				return false
+			}
+		}
+		return true
+
+	case *object.ErrorValue:
+		return a.Message == b.(*object.ErrorValue).Message
+	}
+
+	return identical(a, b)
+}
+
+// identical tells whether 2 values are the same object. Built-in functions, including the methods bound to a value,
+// are Go functions that cannot be told apart, so they are never identical, not even to themselves.
+func identical(a, b object.Object) bool {
+	if _, ok := a.(object.BuiltInFunction); ok {
+		return false
+	}
+	if _, ok := b.(object.BuiltInFunction); ok {
+		return false
+	}
+	return a == b
+}
@@ -6,7 +6,8 @@ import (
 	"github.com/shksa/yeezy/object"
 )
 
-var builtins = map[string]object.BuiltInFunction{
+// defaultBuiltins is the table of built-in functions every new Interpreter starts with.
+var defaultBuiltins = map[string]object.BuiltInFunction{
 	"len": func(args ...object.Object) object.Object {
 		if len(args) != 1 {
 			return newError("Wrong number of arguments. want=%d, got=%d", 1, len(args))
@@ -16,6 +17,12 @@ var builtins = map[string]object.BuiltInFunction{
 		case *object.String:
 			return &object.Integer{Value: int64(len(arg.Value))}
 
+		case *object.Array:
+			return &object.Integer{Value: int64(len(arg.Elements))}
+
+		case *object.Hash:
+			return &object.Integer{Value: int64(len(arg.Keys))}
+
 		default:
 			return newError("len doesn'nt support the given argument. got=%s", args[0].Type())
 		}
@@ -31,4 +38,21 @@ var builtins = map[string]object.BuiltInFunction{
 
 		return NULL
 	},
+	"error": func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("Wrong number of arguments. want=%d, got=%d", 1, len(args))
+		}
+
+		if msg, ok := args[0].(*object.String); ok {
+			return &object.ErrorValue{Message: msg.Value}
+		}
+		return &object.ErrorValue{Message: args[0].Inspect()}
+	},
+	"isError": func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("Wrong number of arguments. want=%d, got=%d", 1, len(args))
+		}
+
+		return nativeBoolToBooleanObject(args[0].Type() == object.ERRORVALUE)
+	},
 }
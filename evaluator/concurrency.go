@@ -0,0 +1,213 @@
+package evaluator
+
+import (
+	"reflect"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/object"
+)
+
+/* Concurrency
+- `spawn f(args)` evaluates f and its arguments like a call, then calls f on a new goroutine and evaluates to a channel
+	that receives what f returns, or an error value if an error stopped it, and is closed afterwards.
+- The spawned call is evaluated by an interpreter of its own, which shares the built-ins, the methods and the imported
+	modules of the interpreter that spawned it, but has its own call stack and no Hook, as hooks watch one goroutine.
+- Spawned functions share the environments of their closures with the caller, object.Environment is safe for
+	concurrent use. Arrays are not, they are better sent through channels than pushed to from many goroutines.
+- `channel(capacity?)` makes a channel, `send(ch, value)` and `receive(ch)` block like they do in Go, and `close(ch)`
+	closes it. receive returns null once a closed channel is empty.
+- `select { case v = receive(ch) { ... } case send(ch, value) { ... } default { ... } }` evaluates the channels and
+	values of all its cases, waits till one of them can go on, and evaluates to the block of that case. With a default
+	case it does not wait.
+*/
+
+// channelBuiltins returns the built-in functions that make and use channels.
+func channelBuiltins() map[string]object.BuiltInFunction {
+	return map[string]object.BuiltInFunction{
+		"channel": func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("Wrong number of arguments. want=0 or 1, got=%d", len(args))
+			}
+			capacity := int64(0)
+			if len(args) == 1 {
+				integer, ok := args[0].(*object.Integer)
+				if !ok || integer.Value < 0 {
+					return newError("the capacity of a channel must be an INTEGER that is not negative, got %s", args[0].Inspect())
+				}
+				capacity = integer.Value
+			}
+			return object.NewChannel(int(capacity))
+		},
+
+		"send": func(args ...object.Object) object.Object {
+			ch, err := channelArgument("send", args, 2)
+			if err != nil {
+				return err
+			}
+			return sendValue(ch, args[1])
+		},
+
+		"receive": func(args ...object.Object) object.Object {
+			ch, err := channelArgument("receive", args, 1)
+			if err != nil {
+				return err
+			}
+			value, ok := <-ch.Values
+			if !ok {
+				return NULL
+			}
+			return value
+		},
+
+		"close": func(args ...object.Object) object.Object {
+			ch, err := channelArgument("close", args, 1)
+			if err != nil {
+				return err
+			}
+			return closeChannel(ch)
+		},
+	}
+}
+
+// channelArgument checks the arguments of a channel built-in, the first one of which is the channel.
+func channelArgument(name string, args []object.Object, want int) (*object.Channel, *object.Error) {
+	if len(args) != want {
+		return nil, newError("Wrong number of arguments. want=%d, got=%d", want, len(args))
+	}
+	ch, ok := args[0].(*object.Channel)
+	if !ok {
+		return nil, newError("argument to `%s` must be CHANNEL, got %s", name, args[0].Type())
+	}
+	return ch, nil
+}
+
+// sendValue sends a value through a channel, sending through a closed channel is an error instead of a panic.
+func sendValue(ch *object.Channel, value object.Object) (result object.Object) {
+	defer func() {
+		if recover() != nil {
+			result = newError("send on a closed channel")
+		}
+	}()
+	ch.Values <- value
+	return NULL
+}
+
+// closeChannel closes a channel, closing it again is an error instead of a panic.
+func closeChannel(ch *object.Channel) (result object.Object) {
+	defer func() {
+		if recover() != nil {
+			result = newError("close of a closed channel")
+		}
+	}()
+	close(ch.Values)
+	return NULL
+}
+
+// spawned returns the interpreter that evaluates a function spawned by in. It has the builtins of in, but the ones
+// bound to in, like assertThrows, are bound to it instead, as they push the frames of their calls to their interpreter.
+func (in *Interpreter) spawned() *Interpreter {
+	child := &Interpreter{
+		SearchPath: in.SearchPath,
+		builtins:   make(map[string]object.BuiltInFunction, len(in.builtins)),
+		bound:      make(map[string]bool, len(in.bound)),
+		methods:    in.methods,
+		modules:    in.modules,
+		modulesMu:  in.modulesMu,
+		loading:    append([]*object.Module(nil), in.loading...), // imports are still resolved relative to the same file
+	}
+	for name, builtInFunc := range in.builtins {
+		child.builtins[name] = builtInFunc
+	}
+	for name, builtInFunc := range child.assertBuiltins() {
+		if in.bound[name] {
+			child.builtins[name] = builtInFunc
+			child.bound[name] = true
+		}
+	}
+	return child
+}
+
+func (in *Interpreter) evaluateSpawnExpression(node *ast.SpawnExpressionNode, env *object.Environment) object.Object {
+	fn := in.Eval(node.Call.Function, env)
+	if isErrorOrReturn(fn) {
+		return fn
+	}
+	if fn.Type() != object.FUNCTION && fn.Type() != object.BUILTINFUNCTION {
+		return newError("not a function %s", fn.Type())
+	}
+
+	args := in.evaluateExpressions(node.Call.Arguments, env)
+	if len(args) == 1 && isErrorOrReturn(args[0]) {
+		return args[0]
+	}
+
+	result := object.NewChannel(1)
+	child := in.spawned()
+	go func() {
+		value := child.applyFunction(fn, args)
+		if errObj, ok := value.(*object.Error); ok {
+			value = &object.ErrorValue{Message: errObj.Message, Stack: errObj.Stack}
+		}
+		if value == nil {
+			value = NULL
+		}
+		result.Values <- value
+		close(result.Values)
+	}()
+	return result
+}
+
+func (in *Interpreter) evaluateSelectExpression(node *ast.SelectExpressionNode, env *object.Environment) object.Object {
+	cases := make([]reflect.SelectCase, 0, len(node.Cases)+1)
+	for _, selectCase := range node.Cases {
+		args := in.evaluateExpressions(selectCase.Operation.Arguments, env)
+		if len(args) == 1 && isErrorOrReturn(args[0]) {
+			return args[0]
+		}
+		ch, ok := args[0].(*object.Channel)
+		if !ok {
+			return newError("argument to `%s` must be CHANNEL, got %s", selectCase.Operation.Function.String(), args[0].Type())
+		}
+
+		if selectCase.IsSend() {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(ch.Values), Send: reflect.ValueOf(&args[1]).Elem()})
+		} else {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch.Values)})
+		}
+	}
+	if node.Default != nil {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+
+	chosen, received, ok, err := waitForCase(cases)
+	if err != nil {
+		return err
+	}
+	if chosen == len(node.Cases) {
+		return in.Eval(node.Default, env)
+	}
+
+	chosenCase := node.Cases[chosen]
+	if chosenCase.Name == nil {
+		return in.Eval(chosenCase.Body, env)
+	}
+	caseEnv := object.NewEnclosedEnvironment(env)
+	value := object.Object(NULL)
+	if ok {
+		value = received.Interface().(object.Object)
+	}
+	caseEnv.Set(chosenCase.Name.Name, value)
+	return in.Eval(chosenCase.Body, caseEnv)
+}
+
+// waitForCase waits for one of the cases like reflect.Select, sending through a closed channel is an error instead of a
+// panic.
+func waitForCase(cases []reflect.SelectCase) (chosen int, received reflect.Value, ok bool, err *object.Error) {
+	defer func() {
+		if recover() != nil {
+			err = newError("send on a closed channel")
+		}
+	}()
+	chosen, received, ok = reflect.Select(cases)
+	return chosen, received, ok, nil
+}
@@ -30,33 +30,66 @@ var (
 - In the top-level, the object.ReturnValue it will be unwrapped to get the actual value and will be returned to the user.
 */
 
-// Eval takes in the AST and evaluates it, returning yeezy objects
+// Eval takes in the AST and evaluates it with a new Interpreter that has the default built-in functions, returning yeezy objects
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New().Eval(node, env)
+}
+
+// Eval takes in the AST and evaluates it, returning yeezy objects
+func (in *Interpreter) Eval(node ast.Node, env *object.Environment) object.Object {
+	if in.Hook != nil {
+		in.Hook.BeforeNode(node, env)
+	}
+
 	switch node := node.(type) {
 
 	// Statements
 	case *ast.Program:
-		return evaluateProgram(node.Statements, env)
+		return in.evaluateProgram(node.Statements, env)
 
 	case *ast.ExpressionStatementNode:
-		return Eval(node.Expression, env)
+		return in.Eval(node.Expression, env)
 
 	case *ast.BlockStatementNode:
-		return evaluateBlockStatement(node, env) // Can return a *object.ReturnValue
+		return in.evaluateBlockStatement(node, env) // Can return a *object.ReturnValue
 
 	case *ast.ReturnStatementNode:
-		value := Eval(node.ReturnValue, env)
-		if isError(value) {
+		value := in.Eval(node.ReturnValue, env)
+		if isErrorOrReturn(value) {
 			return value
 		}
 		return &object.ReturnValue{Value: value} // Need to keep track of return value so that we can decide later whether to stop evaluation or not
 
 	case *ast.LetStatementNode:
-		value := Eval(node.Value, env) // evaluate the expression with the context of current environment.
-		if isError(value) {
+		value := in.Eval(node.Value, env) // evaluate the expression with the context of current environment.
+		if isErrorOrReturn(value) {
+			return value
+		}
+		if _, ok := node.Value.(*ast.FunctionLiteralNode); ok {
+			// The function is named after the let that makes it, for stack traces. A function that was made before can be
+			// shared with other goroutines, so it is not named again.
+			value.(*object.Function).Name = node.Iden.Name
+		}
+		return bind(env, node.Iden.Name, value, node.IsConst())
+
+	case *ast.ImportStatementNode:
+		return in.evaluateImportStatement(node, env)
+
+	case *ast.ExportStatementNode:
+		return in.evaluateExportStatement(node, env)
+
+	case *ast.ThrowStatementNode:
+		value := in.Eval(node.Value, env)
+		if isErrorOrReturn(value) {
 			return value
 		}
-		env.Set(node.Iden.Name, value)
+		return throwValue(value)
+
+	case *ast.StructStatementNode:
+		return in.evaluateStructStatement(node, env)
+
+	case *ast.AssignStatementNode:
+		return in.evaluateAssignStatement(node, env)
 
 	// Expressions
 	case *ast.IntegerLiteralNode:
@@ -69,58 +102,115 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.String{Value: node.Value}
 
 	case *ast.PrefixExpressionNode:
-		operand := Eval(node.Right, env) // operand may be object.Integer, object.Boolean, or object.Null, object.Error
-		if isError(operand) {
+		operand := in.Eval(node.Right, env) // operand may be object.Integer, object.Boolean, or object.Null, object.Error
+		if isErrorOrReturn(operand) {
 			return operand
 		}
 		return evaluatePrefixExpression(node.Operator, operand)
 
 	case *ast.InfixExpressionNode:
-		leftOperand := Eval(node.Left, env) // leftOperand may be object.Integer, object.Boolean, or object.Null, object.Error
-		if isError(leftOperand) {
+		leftOperand := in.Eval(node.Left, env) // leftOperand may be object.Integer, object.Boolean, or object.Null, object.Error
+		if isErrorOrReturn(leftOperand) {
 			return leftOperand
 		}
 
-		rightOperand := Eval(node.Right, env) // rightOperand may be object.Integer, object.Boolean, or object.Null, object.Error
-		if isError(rightOperand) {
+		rightOperand := in.Eval(node.Right, env) // rightOperand may be object.Integer, object.Boolean, or object.Null, object.Error
+		if isErrorOrReturn(rightOperand) {
 			return rightOperand
 		}
 		return evaluateInfixExpression(node.Operator, leftOperand, rightOperand)
 
 	case *ast.IfExpressionNode:
-		return evaluateIfExpression(node, env) // If-expression will return whatever its block statement will return.
+		return in.evaluateIfExpression(node, env) // If-expression will return whatever its block statement will return.
+
+	case *ast.TryExpressionNode:
+		return in.evaluateTryExpression(node, env)
+
+	case *ast.SpawnExpressionNode:
+		return in.evaluateSpawnExpression(node, env)
+
+	case *ast.SelectExpressionNode:
+		return in.evaluateSelectExpression(node, env)
+
+	case *ast.MatchExpressionNode:
+		return in.evaluateMatchExpression(node, env)
+
+	case *ast.PropagateExpressionNode:
+		value := in.Eval(node.Value, env)
+		if isErrorOrReturn(value) {
+			return value
+		}
+		if value.Type() == object.ERRORVALUE {
+			return &object.ReturnValue{Value: value} // The error value is returned from the function the expression is in.
+		}
+		return value
 
 	case *ast.IdentifierNode:
-		return evaluateIdentifier(node, env) // returns object.Integer, object.Boolean or object.Error
+		return in.evaluateIdentifier(node, env) // returns object.Integer, object.Boolean or object.Error
 
 	case *ast.FunctionLiteralNode:
 		params := node.Parameters
 		body := node.Body
 		return &object.Function{Parameters: params, Body: body, Env: env} // A function has a reference to the env it is created in.
 
+	case *ast.MemberExpressionNode:
+		obj := in.Eval(node.Object, env)
+		if isErrorOrReturn(obj) {
+			return obj
+		}
+		return in.evaluateMemberExpression(obj, node.Property.Name)
+
+	case *ast.ArrayLiteralNode:
+		elements := in.evaluateExpressions(node.Elements, env)
+		if len(elements) == 1 && isErrorOrReturn(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+
+	case *ast.StructLiteralNode:
+		return in.evaluateStructLiteral(node, env)
+
+	case *ast.HashLiteralNode:
+		return in.evaluateHashLiteral(node, env)
+
+	case *ast.IndexExpressionNode:
+		left := in.Eval(node.Left, env)
+		if isErrorOrReturn(left) {
+			return left
+		}
+
+		index := in.Eval(node.Index, env)
+		if isErrorOrReturn(index) {
+			return index
+		}
+		return evaluateIndexExpression(left, index)
+
 	case *ast.CallExpressionNode:
-		functionObj := Eval(node.Function, env) // node.Function can be ast.FunctionNode or ast.IdentifierNode. IdentifierNode can evaluate to either a object.Function or any other object type
+		functionObj := in.Eval(node.Function, env) // node.Function can be ast.FunctionNode or ast.IdentifierNode. IdentifierNode can evaluate to either a object.Function or any other object type
 
-		if isError(functionObj) {
+		if isErrorOrReturn(functionObj) {
 			return functionObj
 		}
 
-		args := evaluateExpressions(node.Arguments, env) // evaluate the arguments with context of the current environment
-		if len(args) == 1 && isError(args[0]) {
+		args := in.evaluateExpressions(node.Arguments, env) // evaluate the arguments with context of the current environment
+		if len(args) == 1 && isErrorOrReturn(args[0]) {
 			return args[0]
 		}
 
-		return applyFunction(functionObj, args)
+		return in.applyFunction(functionObj, args)
 	}
 
 	return nil
 }
 
-func evaluateProgram(stmtNodes []ast.StatementNode, env *object.Environment) object.Object {
+func (in *Interpreter) evaluateProgram(stmtNodes []ast.StatementNode, env *object.Environment) object.Object {
 	var result object.Object
 
+	in.pushFrame(in.programName(), env)
+	defer in.popFrame()
+
 	for _, stmtNode := range stmtNodes {
-		result = Eval(stmtNode, env)
+		result = in.evaluateStatement(stmtNode, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue: // Evaluation of further statements is ended because a return statement is encountered.
@@ -193,12 +283,20 @@ func evaluateInfixExpression(operator string, leftOperand, rightOperand object.O
 	case leftOperand.Type() == object.STRING && rightOperand.Type() == object.STRING:
 		return evaluateStringInfixExpression(operator, leftOperand, rightOperand)
 
-	// For the next cases, the leftOperand and rightOperand are *object.Boolean, either TRUE or FALSE values
+	// Structs and hashes are compared by their values.
+	case (leftOperand.Type() == object.STRUCT || leftOperand.Type() == object.HASH) && operator == "==":
+		return nativeBoolToBooleanObject(objectsEqual(leftOperand, rightOperand))
+
+	case (leftOperand.Type() == object.STRUCT || leftOperand.Type() == object.HASH) && operator == "!=":
+		return nativeBoolToBooleanObject(!objectsEqual(leftOperand, rightOperand))
+
+	// For the next cases, the leftOperand and rightOperand are *object.Boolean, either TRUE or FALSE values, or other
+	// objects that are compared by identity
 	case operator == "==":
-		return nativeBoolToBooleanObject(leftOperand == rightOperand) // Pointer comparision to check for equality b/w 2 boolean object pointers.
+		return nativeBoolToBooleanObject(identical(leftOperand, rightOperand)) // Pointer comparision to check for equality b/w 2 boolean object pointers.
 
 	case operator == "!=":
-		return nativeBoolToBooleanObject(leftOperand != rightOperand)
+		return nativeBoolToBooleanObject(!identical(leftOperand, rightOperand))
 
 	default:
 		return newError("invalid operator %q between %s values: %s %s %s", operator, leftOperand.Type(), leftOperand.Inspect(), operator, rightOperand.Inspect())
@@ -216,6 +314,9 @@ func evaluateIntegerInfixExpression(operator string, leftOperand, rightOperand o
 	case "*":
 		return &object.Integer{Value: leftValue * rightValue}
 	case "/":
+		if rightValue == 0 {
+			return newError("division by zero: %d / %d", leftValue, rightValue)
+		}
 		return &object.Integer{Value: leftValue / rightValue}
 	case "<":
 		return nativeBoolToBooleanObject(leftValue < rightValue)
@@ -226,7 +327,7 @@ func evaluateIntegerInfixExpression(operator string, leftOperand, rightOperand o
 	case "!=":
 		return nativeBoolToBooleanObject(leftValue != rightValue)
 	default:
-		return newError("invalid operator %q between %s values: %s %s %s", operator, leftOperand.Type(), leftValue, operator, rightValue)
+		return newError("invalid operator %q between %s values: %d %s %d", operator, leftOperand.Type(), leftValue, operator, rightValue)
 	}
 }
 
@@ -241,17 +342,17 @@ func evaluateStringInfixExpression(operator string, leftOperand, rightOperand ob
 	}
 }
 
-func evaluateIfExpression(node *ast.IfExpressionNode, env *object.Environment) object.Object {
-	conditionValue := Eval(node.Condition, env)
+func (in *Interpreter) evaluateIfExpression(node *ast.IfExpressionNode, env *object.Environment) object.Object {
+	conditionValue := in.Eval(node.Condition, env)
 
-	if isError(conditionValue) {
+	if isErrorOrReturn(conditionValue) {
 		return conditionValue
 	}
 
 	if isTruthy(conditionValue) {
-		return Eval(node.Consequence, env)
+		return in.Eval(node.Consequence, env)
 	} else if node.Alternative != nil {
-		return Eval(node.Alternative, env)
+		return in.Eval(node.Alternative, env)
 	} else {
 		return NULL
 	}
@@ -270,11 +371,11 @@ func isTruthy(obj object.Object) bool {
 	}
 }
 
-func evaluateBlockStatement(block *ast.BlockStatementNode, env *object.Environment) object.Object { // can return object.ReturnValue if the block has return statements.
+func (in *Interpreter) evaluateBlockStatement(block *ast.BlockStatementNode, env *object.Environment) object.Object { // can return object.ReturnValue if the block has return statements.
 	var result object.Object
 
 	for _, statement := range block.Statements {
-		result = Eval(statement, env)
+		result = in.evaluateStatement(statement, env)
 
 		if result != nil {
 			resultType := result.Type()
@@ -285,6 +386,9 @@ func evaluateBlockStatement(block *ast.BlockStatementNode, env *object.Environme
 		}
 	}
 
+	if result == nil { // A block is a value, even when it is empty or ends with a let statement.
+		return NULL
+	}
 	return result
 }
 
@@ -299,26 +403,47 @@ func isError(obj object.Object) bool {
 	return false
 }
 
-func evaluateIdentifier(idenNode *ast.IdentifierNode, env *object.Environment) object.Object {
+// isErrorOrReturn tells whether the evaluation of an expression has to stop because one of its operands evaluated
+// to an error, or to a return value made by the "?" operator.
+func isErrorOrReturn(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROROBJ || obj.Type() == object.RETURNOBJ
+	}
+	return false
+}
+
+// bind binds a name to a value in the environment, binding it in a read-only environment, or binding a name that is
+// bound to a constant in the environment, is an error.
+func bind(env *object.Environment, name string, value object.Object, constant bool) object.Object {
+	switch env.Declare(name, value, constant) {
+	case object.ErrReadOnly:
+		return newError("cannot bind %s in a read-only environment", name)
+	case object.ErrConstant:
+		return newError("cannot bind %s again, it is a constant", name)
+	}
+	return nil
+}
+
+func (in *Interpreter) evaluateIdentifier(idenNode *ast.IdentifierNode, env *object.Environment) object.Object {
 	if value, ok := env.Get(idenNode.Name); ok {
 		return value
 	}
 
-	if builtInFunc, ok := builtins[idenNode.Name]; ok {
+	if builtInFunc, ok := in.builtins[idenNode.Name]; ok {
 		return builtInFunc
 	}
 
 	return newError("identifier not found: %s", idenNode.Name)
 }
 
-func evaluateExpressions(expressions []ast.ExpressionNode, env *object.Environment) []object.Object {
+func (in *Interpreter) evaluateExpressions(expressions []ast.ExpressionNode, env *object.Environment) []object.Object {
 	var result []object.Object
 
 	for _, exprNode := range expressions {
-		evaluated := Eval(exprNode, env)
+		evaluated := in.Eval(exprNode, env)
 
-		if isError(evaluated) {
-			return append(result, evaluated)
+		if isErrorOrReturn(evaluated) {
+			return []object.Object{evaluated}
 		}
 		result = append(result, evaluated)
 	}
@@ -328,12 +453,17 @@ func evaluateExpressions(expressions []ast.ExpressionNode, env *object.Environme
 
 // The eval. of function call only depends on the env where the function is created, not the env in which
 // the call is evaluated. So the env in which function call is evaluated is irrelavent to the function's body evaluation.
-func applyFunction(funct object.Object, args []object.Object) object.Object {
+func (in *Interpreter) applyFunction(funct object.Object, args []object.Object) object.Object {
 	switch fnObj := funct.(type) {
 
 	case *object.Function:
+		if len(args) < len(fnObj.Parameters) {
+			return newError("%s takes %d arguments, but is called with %d", functionName(fnObj), len(fnObj.Parameters), len(args))
+		}
 		extendedEnv := createExtendedFunctionEnv(fnObj, args) // creates a new env for the function that has a ref to an env in which the function was created.
-		evaluated := Eval(fnObj.Body, extendedEnv)            // The function's body is evaluated with the new environment.
+		in.pushFrame(functionName(fnObj), extendedEnv)
+		evaluated := in.Eval(fnObj.Body, extendedEnv) // The function's body is evaluated with the new environment.
+		in.popFrame()
 		return unwrapReturnValue(evaluated)
 		// Need to unwrap a return value because otherwise it will bubble up through several function calls
 		// and stop the execution in all of them. We only want to stop the execution of the last called function's body.
@@ -341,6 +471,9 @@ func applyFunction(funct object.Object, args []object.Object) object.Object {
 	case object.BuiltInFunction:
 		return fnObj(args...)
 
+	case *object.StructType:
+		return newStruct(fnObj, args)
+
 	}
 
 	return newError("not a function %s", funct.Type())
@@ -363,3 +496,60 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	}
 	return obj
 }
+
+// evaluateMemberExpression returns an exported binding for modules, and a method bound to the object for any other
+// object that has a method with the given name registered for its type.
+func (in *Interpreter) evaluateMemberExpression(obj object.Object, name string) object.Object {
+	switch obj := obj.(type) {
+	case *object.Struct:
+		if fieldIdx, ok := obj.StructType.FieldIndex(name); ok {
+			return obj.Values[fieldIdx]
+		}
+		if method, ok := in.Method(obj.Type(), name); ok {
+			return bindMethod(method, obj)
+		}
+		return newError("%s has no field %s", obj.StructType.Name, name)
+
+	case *object.Module:
+		if value, ok := obj.Exports[name]; ok {
+			return value
+		}
+		return newError("module %s has no exported member %s", obj.Name, name)
+
+	case *object.ErrorValue:
+		switch name {
+		case "message":
+			return &object.String{Value: obj.Message}
+		case "stack":
+			frames := []object.Object{}
+			for _, frame := range obj.Stack {
+				frames = append(frames, &object.String{Value: frame})
+			}
+			return &object.Array{Elements: frames}
+		}
+	}
+
+	if method, ok := in.Method(obj.Type(), name); ok {
+		return bindMethod(method, obj)
+	}
+
+	return newError("%s has no member %s", obj.Type(), name)
+}
+
+func evaluateIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY && index.Type() == object.INTEGER:
+		elements := left.(*object.Array).Elements
+		idx := index.(*object.Integer).Value
+		if idx < 0 || idx >= int64(len(elements)) {
+			return newError("index out of range: %d with length %d", idx, len(elements))
+		}
+		return elements[idx]
+
+	case left.Type() == object.HASH:
+		return evaluateHashIndexExpression(left.(*object.Hash), index)
+
+	default:
+		return newError("index operator not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
@@ -1,8 +1,15 @@
 package evaluator
 
 import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/shksa/yeezy/ast"
 	"github.com/shksa/yeezy/lexer"
 	"github.com/shksa/yeezy/object"
 	"github.com/shksa/yeezy/parser"
@@ -146,6 +153,10 @@ func TestBooleanExpressionEval(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{"len == len", false},
+		{"len != len", true},
+		{`let s = "a".upper; s == s`, false},
+		{"let f = func() { 1 }; f == f", true},
 	}
 
 	for _, tt := range tests {
@@ -195,6 +206,7 @@ func TestIfElseExpression(t *testing.T) {
 		expected interface{}
 	}{
 		{"if (true) { 10 }", 10},
+		{"if (true) { let x = 10 }", nil},
 		{"if (false) { 10 }", nil},
 		{"if (1) { 10 }", 10},
 		{"if (1 < 2) { 10 }", 10},
@@ -281,6 +293,22 @@ func TestErrorHandling(t *testing.T) {
 			"if (10 > 1) { true + false; }",
 			`invalid operator "+" between BOOLEAN values: true + false`,
 		},
+		{
+			"[1, true + false, 3]",
+			`invalid operator "+" between BOOLEAN values: true + false`,
+		},
+		{
+			"let f = func(a, b) { a }; f(1, -true)",
+			`invalid prefix operator "-" for operand type BOOLEAN`,
+		},
+		{
+			"let zero = 0; 10 / zero",
+			"division by zero: 10 / 0",
+		},
+		{
+			"let f = func(a, b) { a }; f(1)",
+			"f takes 2 arguments, but is called with 1",
+		},
 		{
 			`
 			if (10 > 1) {
@@ -350,6 +378,10 @@ func TestLetStatements(t *testing.T) {
 		{`let foo = "bar"; foo;`, "bar"},
 		{`let foo = ""; foo;`, ""},
 		{`let message = "hello" + " " + "world!"; message`, "hello world!"},
+		{"let a = 5; let a = a + 1; a", 6},
+		{"const a = 5; a * 2", 10},
+		{"let a = 5; const a = 6; a", 6},
+		{"const a = 5; let f = func() { let a = 6; a }; f() + a", 11},
 	}
 
 	for _, tt := range tests {
@@ -358,6 +390,23 @@ func TestLetStatements(t *testing.T) {
 			return
 		}
 	}
+
+	errorTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"const a = 5; let a = 6", "cannot bind a again, it is a constant"},
+		{"const a = 5; const a = 6", "cannot bind a again, it is a constant"},
+		{"const a = 5; if (true) { let a = 6 }", "cannot bind a again, it is a constant"},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expectedMessage {
+			t.Errorf("%s: expected the error %q, got=%T (%+v)", tt.input, tt.expectedMessage, evaluated, evaluated)
+		}
+	}
 }
 
 func TestFunctionObject(t *testing.T) {
@@ -399,6 +448,7 @@ func TestFunctionApplication(t *testing.T) {
 		{"func(g) { g; }(5)", 5},
 		{"let isFat = func(weight) { if(weight > 50) { true } }; isFat(55)", true},
 		{`func(message) { message }("hello" + " " + "world!")`, "hello world!"},
+		{"let nothing = func() {}; [nothing()][0]", nil},
 	}
 
 	for _, tt := range tests {
@@ -438,3 +488,893 @@ func TestLenBuiltInFunction(t *testing.T) {
 		testIntegerObject(t, evaluated, tt.expectedOutput)
 	}
 }
+
+func TestInterpreterBuiltins(t *testing.T) {
+	fake := func(args ...object.Object) object.Object { return &object.Integer{Value: 42} }
+
+	tests := []struct {
+		input    string
+		setup    func(in *Interpreter)
+		expected interface{}
+	}{
+		{`len("foo")`, func(in *Interpreter) {}, 3},
+		{`len("foo")`, func(in *Interpreter) { in.SetBuiltin("len", fake) }, 42},
+		{`answer()`, func(in *Interpreter) { in.SetBuiltin("answer", fake) }, 42},
+		{`let len = func(x) { 7 }; len("foo")`, func(in *Interpreter) {}, 7},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		tt.setup(in)
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		evaluated := in.Eval(p.ParseProgram(), object.NewEnvironment())
+		if !testObject(t, evaluated, tt.expected) {
+			return
+		}
+	}
+
+	in := New()
+	in.RemoveBuiltin("len")
+	evaluated := in.Eval(parser.New(lexer.New(`len("foo")`)).ParseProgram(), object.NewEnvironment())
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "identifier not found: len" {
+		t.Errorf("removed built-in is still visible. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if _, ok := New().Builtin("len"); !ok {
+		t.Errorf("removing a built-in from one interpreter removed it from a new interpreter")
+	}
+
+	if names := NewSandboxed().BuiltinNames(); len(names) != 0 {
+		t.Errorf("sandboxed interpreter has built-ins. got=%v", names)
+	}
+}
+
+func writeModuleFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testEvalFile(in *Interpreter, filePath, input string) object.Object {
+	p := parser.New(lexer.New(input))
+	return in.EvalFile(filePath, p.ParseProgram(), object.NewEnvironment())
+}
+
+func TestModules(t *testing.T) {
+	dir := writeModuleFiles(t, map[string]string{
+		"math.yz": `
+			let helper = func(x) { x * 2 };
+			export let double = func(x) { helper(x) };
+			export let answer = 42;
+			export const pi = 3;
+		`,
+		"a.yz": `import "b.yz"; export let x = 1;`,
+		"b.yz": `import "a.yz"; export let y = 2;`,
+	})
+	mainFile := filepath.Join(dir, "main.yz")
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`import "math.yz" as m; m.double(m.answer)`, 84},
+		{`import "math.yz"; math.answer`, 42},
+		{`import "math.yz" as m; import "math.yz" as n; m == n`, true},
+		{`import "math.yz" as m; m.pi`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalFile(New(), mainFile, tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			return
+		}
+	}
+
+	errorTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`import "math.yz" as m; m.helper`, "module math has no exported member helper"},
+		{`import "nope.yz"`, `module not found: "nope.yz"`},
+		{`const m = 1; import "math.yz" as m`, "cannot bind m again, it is a constant"},
+		{`import "a.yz"`, "import cycle: " + strings.Join([]string{
+			filepath.Join(dir, "a.yz"), filepath.Join(dir, "b.yz"), filepath.Join(dir, "a.yz"),
+		}, " -> ")},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEvalFile(New(), mainFile, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%s, got=%s", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestModuleSearchPath(t *testing.T) {
+	libDir := writeModuleFiles(t, map[string]string{"lib.yz": `export let v = 7`})
+
+	in := New()
+	in.SearchPath = []string{libDir}
+	evaluated := testEvalFile(in, filepath.Join(t.TempDir(), "main.yz"), `import "lib.yz"; lib.v`)
+	testIntegerObject(t, evaluated, 7)
+}
+
+func TestHashes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"one": 1, "two": 2}["two"]`, 2},
+		{`let key = "k"; {key: 1 + 1, 2: "two", true: 3}[key]`, 2},
+		{`{2: "two"}[1 + 1]`, "two"},
+		{`{true: 3}[1 < 2]`, 3},
+		{`{1: "one"}["1"]`, nil},
+		{`{}["missing"]`, nil},
+		{`{"a": 1, "a": 2}["a"]`, 2},
+		{`len({"a": 1, "b": 2, "a": 3})`, 2},
+		{`{"a": 1}.len()`, 1},
+		{`{"b": 1, "a": 2, "b": 3}.keys().join(",")`, "b,a"},
+		{`let vs = {"a": 1, "b": 2}.values(); vs[0] + vs[1]`, 3},
+		{`{"a": [1], "b": 2} == {"b": 2, "a": [1]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} != {"a": 1, "b": 2}`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("input: %s", tt.input)
+		}
+	}
+
+	inspectTests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, 2: [true], "a": {}}`, "{b: 1, 2: [true], a: {}}"},
+		{`let xs = []; let h = {"xs": xs}; xs.push(h); h`, "{xs: [{...}]}"},
+	}
+
+	for _, tt := range inspectTests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+
+	errorTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`{[1]: 1}`, "unusable as hash key: ARRAY"},
+		{`{"a": 1}[func() {}]`, "unusable as hash key: FUNCTION"},
+		{`{"a": undefined}`, "identifier not found: undefined"},
+		{`{"a": 1} + {"b": 2}`, `invalid operator "+" between HASH values: {a: 1} + {b: 2}`},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expectedMessage {
+			t.Errorf("%s: expected the error %q, got=%T (%+v)", tt.input, tt.expectedMessage, evaluated, evaluated)
+		}
+	}
+}
+
+func TestArrays(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2 * 2, 3 + 3][1]", 4},
+		{"let i = 0; [1][i]", 1},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2]", 6},
+		{`len([1, "two", true])`, 3},
+		{`[[1, 2], [3]][0][1]`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			return
+		}
+	}
+
+	evaluated := testEval("[1, 2][2]")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "index out of range: 2 with length 2" {
+		t.Errorf("wrong result for out of range index. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	evaluated = testEval("let xs = [1]; xs.push(xs); [xs, [2]]")
+	if evaluated.Inspect() != "[[1, [...]], [2]]" {
+		t.Errorf("wrong inspection of an array that contains itself. got=%q", evaluated.Inspect())
+	}
+}
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"abc".upper()`, "ABC"},
+		{`"ABC".lower()`, "abc"},
+		{`let s = "  hi "; s.trim().len()`, 2},
+		{`"a,b,c".split(",").join("-")`, "a-b-c"},
+		{`"hello".contains("ell")`, true},
+		{`let arr = [1]; arr.push(2); arr.push(3); arr.len()`, 3},
+		{`let arr = [1, 2]; arr.pop() + arr.len()`, 3},
+		{`[].pop()`, nil},
+		{`let up = "abc".upper; up()`, "ABC"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			return
+		}
+	}
+
+	errorTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`"abc".nope()`, "STRING has no member nope"},
+		{`5.upper()`, "INTEGER has no member upper"},
+		{`"abc".upper(1)`, "Wrong number of arguments. want=0, got=1"},
+		{`"abc".contains(1)`, "argument 1 must be STRING. got=INTEGER"},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%s, got=%s", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestRegisterMethod(t *testing.T) {
+	in := New()
+	in.RegisterMethod(object.INTEGER, "double", func(args ...object.Object) object.Object {
+		return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+	})
+	in.RemoveMethod(object.STRING, "upper")
+
+	evaluated := in.Eval(parser.New(lexer.New("let x = 21; x.double()")).ParseProgram(), object.NewEnvironment())
+	testIntegerObject(t, evaluated, 42)
+
+	evaluated = in.Eval(parser.New(lexer.New(`"abc".upper()`)).ParseProgram(), object.NewEnvironment())
+	if _, ok := evaluated.(*object.Error); !ok {
+		t.Errorf("removed method is still callable. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if _, ok := New().Method(object.INTEGER, "double"); ok {
+		t.Errorf("method registered on one interpreter is visible on a new interpreter")
+	}
+}
+
+func TestTryCatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { throw "bad input" } catch (e) { e.message }`, "bad input"},
+		{`try { throw 5 } catch (e) { e.message }`, "5"},
+		{`try { 1 + true } catch (e) { e.message }`, `operand type mismatch for operator "+" : INTEGER + BOOLEAN`},
+		{`let x = try { 5 } catch (e) { 0 }; x`, 5},
+		{`try { throw "x" } catch { 10 }`, 10},
+		{`let log = []; try { throw "x" } catch (e) { log.push("catch") } finally { log.push("finally") }; log.join(",")`, "catch,finally"},
+		{`let log = []; try { log.push("try") } finally { log.push("finally") }; log.join(",")`, "try,finally"},
+		{`try { try { throw "inner" } catch (e) { throw e } } catch (e) { e.message }`, "inner"},
+		{`let f = func() { try { return 1 } finally { 2 }; 3 }; f()`, 1},
+		{`let f = func() { try { throw "x" } catch (e) { return 2 }; 3 }; f()`, 2},
+		{`try { throw "x" } catch (e) { 1 }; e`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if tt.expected == nil {
+			if _, ok := evaluated.(*object.Error); !ok {
+				t.Errorf("catch parameter leaked out of the catch block. got=%T (%+v)", evaluated, evaluated)
+			}
+			continue
+		}
+		if !testObject(t, evaluated, tt.expected) {
+			return
+		}
+	}
+
+	errorTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`throw "uncaught"; 5`, "uncaught"},
+		{`try { throw "x" } finally { 1 }`, "x"},
+		{`try { 1 } catch (e) { 2 } finally { throw "from finally" }`, "from finally"},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%s, got=%s", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	input := `let fail = func() {
+	throw "boom"
+}
+let wrapper = func() {
+	fail()
+}
+try { wrapper() } catch (e) { e.stack.join("; ") }`
+
+	testStringObject(t, testEval(input), "at fail (line 2); at wrapper (line 5); at <program> (line 7)")
+
+	evaluated := testEval("let f = func() {\n1 + true\n}\nf()")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := []string{"at f (line 2)", "at <program> (line 4)"}
+	if strings.Join(errObj.Stack, "; ") != strings.Join(expected, "; ") {
+		t.Errorf("wrong stack. expected=%v, got=%v", expected, errObj.Stack)
+	}
+}
+
+func TestErrorValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`isError(error("bad"))`, true},
+		{`isError("bad")`, false},
+		{`error("bad").message`, "bad"},
+		{`let e = error("bad"); 5`, 5},
+		{`let parse = func(x) { if (len(x) == 0) { return error("empty") } x }; parse("a")`, "a"},
+		{`let parse = func(x) { if (len(x) == 0) { return error("empty") } x };
+		  let twice = func(x) { let v = parse(x)?; v + v };
+		  twice("ab")`, "abab"},
+		{`let parse = func(x) { if (len(x) == 0) { return error("empty") } x };
+		  let twice = func(x) { let v = parse(x)?; v + v };
+		  twice("").message`, "empty"},
+		{`let parse = func(x) { if (len(x) == 0) { return error("empty") } x };
+		  let twice = func(x) { parse(x)? + parse(x)? };
+		  isError(twice(""))`, true},
+		{`let f = func() { [error("inner")?, 2] }; f().message`, "inner"},
+		{`5?`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			return
+		}
+	}
+
+	evaluated := testEval(`error("top")?; 5`)
+	errValue, ok := evaluated.(*object.ErrorValue)
+	if !ok || errValue.Message != "top" {
+		t.Errorf("? did not stop the program with the error value. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+// recordingHook records the nodes an interpreter evaluates, and the call stack at every statement.
+type recordingHook struct {
+	interpreter *Interpreter
+	nodes       []string
+	stacks      []string
+}
+
+func (h *recordingHook) BeforeNode(node ast.Node, env *object.Environment) {
+	h.nodes = append(h.nodes, node.String())
+	if _, ok := node.(*ast.ExpressionStatementNode); ok {
+		frames := []string{}
+		for _, frame := range h.interpreter.CallStack() {
+			frames = append(frames, fmt.Sprintf("%s:%d", frame.Name, frame.Line))
+		}
+		h.stacks = append(h.stacks, strings.Join(frames, " "))
+	}
+}
+
+func TestHook(t *testing.T) {
+	p := parser.New(lexer.New("let double = func(x) {\n  x * 2\n}\ndouble(1 + 2)"))
+	program := p.ParseProgram()
+
+	interpreter := New()
+	hook := &recordingHook{interpreter: interpreter}
+	interpreter.Hook = hook
+	interpreter.Eval(program, object.NewEnvironment())
+
+	expectedNodes := []string{
+		"let double = func(x) {(x * 2);};double((1 + 2));", "let double = func(x) {(x * 2);};", "func(x) {(x * 2);}",
+		"double((1 + 2));", "double((1 + 2))", "double", "(1 + 2)", "1", "2", "{(x * 2);}", "(x * 2);", "(x * 2)", "x", "2",
+	}
+	if strings.Join(hook.nodes, "\n") != strings.Join(expectedNodes, "\n") {
+		t.Errorf("wrong nodes.\nexpected=%q\ngot=     %q", expectedNodes, hook.nodes)
+	}
+
+	expectedStacks := []string{"<program>:4", "double:2 <program>:4"}
+	if strings.Join(hook.stacks, "\n") != strings.Join(expectedStacks, "\n") {
+		t.Errorf("wrong call stacks.\nexpected=%q\ngot=     %q", expectedStacks, hook.stacks)
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      interface{}
+		expectedError string
+	}{
+		{`assert(1 < 2); 5`, 5, ""},
+		{`assert(1 > 2)`, nil, "assert failed: false is not truthy"},
+		{`assert(false, "no value")`, nil, "no value"},
+		{`assertEqual([1, ["a"], true], [1, ["a"], true]); 5`, 5, ""},
+		{`assertEqual("a" + "b", "ab"); 5`, 5, ""},
+		{`assertEqual([1, 2], [1, 3])`, nil, "assertEqual failed: expected [1, 3], got [1, 2]"},
+		{`assertEqual(1, "1")`, nil, `assertEqual failed: expected 1, got 1`},
+		{`assertEqual(error("a"), error("a")); 5`, 5, ""},
+		{`let xs = [1]; xs.push(xs); let ys = [1]; ys.push(ys); assertEqual(xs, ys); assertEqual(xs, [1, xs]); 5`, 5, ""},
+		{`assertThrows(func() { throw "boom" }).message`, "boom", ""},
+		{`assertThrows(func() { len(1, 2) }, "Wrong number of arguments. want=1, got=2").message`, "Wrong number of arguments. want=1, got=2", ""},
+		{`assertThrows(func() { 5 })`, nil, "assertThrows failed: the function returned 5 instead of throwing"},
+		{`assertThrows(func() { throw "a" }, "b")`, nil, `assertThrows failed: expected the error "b", got "a"`},
+		{`try { assertEqual(1, 2) } catch (e) { e.message }`, "assertEqual failed: expected 2, got 1", ""},
+		{`assert()`, nil, "Wrong number of arguments. want=1 or 2, got=0"},
+		{`assertEqual(len, len)`, nil, "assertEqual failed: expected built-in function, got built-in function"},
+		{`let f = func() { 1 }; assertEqual([f, 1], [f, 1]); 5`, 5, ""},
+		{`assertThrows(func() { 5 }, 1)`, nil, "assertThrows failed: the function returned 5 instead of throwing"},
+		{`assertThrows(func() { throw "a" }, 1)`, nil, "assertThrows doesn't support the given message. got=INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if tt.expectedError != "" {
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != tt.expectedError {
+				t.Errorf("%s: expected the error %q, got=%T (%+v)", tt.input, tt.expectedError, evaluated, evaluated)
+			}
+			continue
+		}
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("input: %s", tt.input)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	interpreter := New()
+	env := object.NewEnvironment()
+	interpreter.Eval(parser.New(lexer.New("let add = func(a, b) { a + b }")).ParseProgram(), env)
+	add, _ := env.Get("add")
+
+	testIntegerObject(t, interpreter.Call(add, &object.Integer{Value: 1}, &object.Integer{Value: 2}), 3)
+
+	errObj, ok := interpreter.Call(add, &object.Integer{Value: 1}).(*object.Error)
+	if !ok || errObj.Message != "add takes 2 arguments, but is called with 1" {
+		t.Errorf("Call with too few arguments did not return an error. got=%+v", errObj)
+	}
+
+	lenFn, _ := interpreter.Builtin("len")
+	testIntegerObject(t, interpreter.Call(lenFn, &object.String{Value: "abc"}), 3)
+}
+
+func TestConcurrency(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let ch = channel(1); send(ch, 5); receive(ch)`, 5},
+		{`let ch = channel(); close(ch); receive(ch)`, nil},
+		{`let square = func(x) { x * x }; receive(spawn square(7))`, 49},
+		{`let result = spawn func() { throw "boom" }(); receive(result).message`, "boom"},
+		{`let result = spawn func() { let x = 1 }(); receive(result); receive(result)`, nil},
+		{`receive(spawn len("four"))`, 4},
+		{`let ch = channel(); spawn send(ch, "hi"); receive(ch)`, "hi"},
+		// Every worker adds the numbers it receives till it receives a number that is not positive, and sends its sum.
+		{`let jobs = channel(); let sums = channel(4)
+let worker = func(sum) { let n = receive(jobs); if (n < 1) { send(sums, sum) } else { worker(sum + n) } }
+let start = func(count) { if (count > 0) { spawn worker(0); start(count - 1) } }
+start(4)
+let feed = func(n) { send(jobs, n); if (n > -3) { feed(n - 1) } }
+spawn feed(100)
+receive(sums) + receive(sums) + receive(sums) + receive(sums)`, 5050},
+		// Spawned functions look names up in the environment the program keeps binding names in.
+		{`let counter = channel(1)
+send(counter, 0)
+let add = func(n) { send(counter, receive(counter) + n) }
+let results = channel(10)
+let spawnAll = func(n) { if (n > 0) { let r = spawn add(n); spawn func() { send(results, receive(r)) }(); spawnAll(n - 1) } }
+spawnAll(10)
+let wait = func(count) { if (count > 0) { receive(results); wait(count - 1) } }
+wait(10)
+receive(counter)`, 55},
+		// assertThrows pushes the frames of its call to the interpreter of the goroutine that calls it.
+		{`let check = func(n) { if (n > 0) { assertThrows(func() { throw "x" }, "x"); check(n - 1) } else { "done" } }
+let results = [spawn check(50), spawn check(50)]
+check(50);
+[receive(results[0]), receive(results[1])][1]`, "done"},
+		{`let ch = channel(); select { case v = receive(ch) { v } default { "nothing" } }`, "nothing"},
+		{`let a = channel(1); let b = channel(1); send(b, 2); select { case x = receive(a) { x } case y = receive(b) { y * 10 } }`, 20},
+		{`let ch = channel(); close(ch); select { case v = receive(ch) { v } }`, nil},
+		{`let ch = channel(1); select { case send(ch, 3) { receive(ch) + 1 } }`, 4},
+		{`let ch = channel(1); send(ch, 1); select { case receive(ch) { "received" } }`, "received"},
+		{`let ch = channel(1); send(ch, 1); select { case v = receive(ch) { v } }; v`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if errObj, ok := evaluated.(*object.Error); ok && tt.expected == nil {
+			if errObj.Message != "identifier not found: v" {
+				t.Errorf("%s: unexpected error %q", tt.input, errObj.Message)
+			}
+			continue
+		}
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("input: %s", tt.input)
+		}
+	}
+
+	errorTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`let ch = channel(); close(ch); send(ch, 1)`, "send on a closed channel"},
+		{`let ch = channel(); close(ch); close(ch)`, "close of a closed channel"},
+		{`let ch = channel(); close(ch); select { case send(ch, 1) { 1 } }`, "send on a closed channel"},
+		{`channel(-1)`, "the capacity of a channel must be an INTEGER that is not negative, got -1"},
+		{`receive(5)`, "argument to `receive` must be CHANNEL, got INTEGER"},
+		{`select { case receive([]) { 1 } }`, "argument to `receive` must be CHANNEL, got ARRAY"},
+		{`let x = 5; spawn x()`, "not a function INTEGER"},
+		{`spawn len(undefined)`, "identifier not found: undefined"},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expectedMessage {
+			t.Errorf("%s: expected the error %q, got=%T (%+v)", tt.input, tt.expectedMessage, evaluated, evaluated)
+		}
+	}
+}
+
+func TestSharedEnvironment(t *testing.T) {
+	base := object.NewEnvironment()
+	prelude := parser.New(lexer.New(`let greeting = "hello"; let greet = func(name) { greeting + " " + name }; let fs = [func(x) { x + 1 }]`)).ParseProgram()
+	if result := New().Eval(prelude, base); isError(result) {
+		t.Fatalf("prelude: %s", result.Inspect())
+	}
+	base.Freeze()
+
+	// Every goroutine evaluates a program of its own against the shared base, with an Interpreter of its own. Binding a
+	// function of the base again must not change it, which go test -race checks.
+	var wg sync.WaitGroup
+	results := make([]object.Object, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			program := parser.New(lexer.New(fmt.Sprintf(`let greeting = "hi"; let name = "%d"; let h = fs[0]; h(1); greet(name)`, i))).ParseProgram()
+			results[i] = New().Eval(program, object.NewEnclosedEnvironment(base))
+		}(i)
+	}
+	wg.Wait()
+	for i, result := range results {
+		testStringObject(t, result, fmt.Sprintf("hello %d", i))
+	}
+	if _, ok := base.Get("name"); ok {
+		t.Errorf("a binding of a program leaked into the shared environment")
+	}
+
+	evaluated := New().Eval(parser.New(lexer.New(`let greeting = "hi"`)).ParseProgram(), base)
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != "cannot bind greeting in a read-only environment" {
+		t.Errorf("expected an error for binding in a frozen environment, got=%T (%+v)", evaluated, evaluated)
+	}
+
+	env := object.NewEnvironment()
+	env.Set("x", &object.Integer{Value: 1})
+	inner := object.NewEnclosedEnvironment(env)
+	inner.Set("y", &object.Integer{Value: 2})
+	snapshot := inner.Snapshot()
+	env.Set("x", &object.Integer{Value: 10})
+	inner.Set("z", &object.Integer{Value: 3})
+	testObject(t, New().Eval(parser.New(lexer.New("x + y")).ParseProgram(), snapshot), 3)
+	if _, ok := snapshot.Get("z"); ok || !snapshot.ReadOnly() || snapshot.Outer() != nil {
+		t.Errorf("expected a read-only snapshot without later bindings or an enclosing environment")
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"struct Point { x, y }; let p = Point(1, 2); p.x + p.y", 3},
+		{"struct Point { x, y }; Point{y: 2, x: 1}.y", 2},
+		{"struct Point { x, y }; Point(1, 2) == Point{x: 1, y: 2}", true},
+		{"struct Point { x, y }; Point(1, [2]) == Point(1, [2])", true},
+		{"struct Point { x, y }; Point(1, 2) != Point(2, 1)", true},
+		{"struct A { x }; struct B { x }; A(1) == B(1)", false},
+		{"struct A { x }; let a = A(1); let make = func() { struct A { x }; A(1) }; a == make()", false},
+		{"struct Point { x, y }; let p = Point(1, 2); let q = p; q.x = 10; p.x", 10},
+		{"struct Counter { n }; let c = Counter(0); let inc = func() { c.n = c.n + 1 }; inc(); inc(); c.n", 2},
+		{"struct Line { start, end }; struct Point { x, y }; let l = Line(Point(0, 0), Point(1, 1)); l.end.x = 5; l.end.x", 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("input: %s", tt.input)
+		}
+	}
+
+	inspectTests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }; Point(1, 2)", "Point{x: 1, y: 2}"},
+		{`struct Point { x, y }; Point{y: "b", x: [Point(1, 2)]}`, "Point{x: [Point{x: 1, y: 2}], y: b}"},
+		{"struct Unit {}; Unit()", "Unit{}"},
+		{"struct Node { next }; let n = Node(1); n.next = [n]; n", "Node{next: [Node{...}]}"},
+		{"struct Point { x, y }; Point", "struct Point { x, y }"},
+	}
+
+	for _, tt := range inspectTests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+
+	errorTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"struct Point { x, y }; Point(1)", "Point takes 2 arguments, but is called with 1"},
+		{"struct Point { x, y }; Point{x: 1}", "Point{...} has no value for the field y"},
+		{"struct Point { x, y }; Point{x: 1, y: 2, z: 3}", "Point has no field z"},
+		{"struct Point { x, y }; Point(1, 2).z", "Point has no field z"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.z = 3", "Point has no field z"},
+		{"let xs = [1]; xs.len = 2", "cannot assign to the field len of ARRAY, only to the fields of a STRUCT"},
+		{"let Point = 1; Point{x: 1}", "not a struct type INTEGER"},
+		{"struct Point { x, y }; Point(1, 2) + Point(1, 2)", `invalid operator "+" between STRUCT values: Point{x: 1, y: 2} + Point{x: 1, y: 2}`},
+		{"struct Point { x, y }; let p = Point(1, 2); p.x = undefined", "identifier not found: undefined"},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expectedMessage {
+			t.Errorf("%s: expected the error %q, got=%T (%+v)", tt.input, tt.expectedMessage, evaluated, evaluated)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match (2) { 1 => "one", 2 => "two", _ => "many" }`, "two"},
+		{`match (-3) { 3 => 1, -3 => 2 }`, 2},
+		{`match ("b") { "a" => 1, "b" => 2 }`, 2},
+		{`match (1 < 2) { false => 0, true => 1 }`, 1},
+		{`match (5) { n => n * 2 }`, 10},
+		{`match ([1, 2]) { [a] => a, [a, b, c] => a, [a, b] => a + b }`, 3},
+		{`match ([1, [2, 3]]) { [a, [_, c]] => a + c }`, 4},
+		{`match ([]) { [] => 0, _ => 1 }`, 0},
+		{`match ([1, 2]) { [1, x] => x, _ => 0 }`, 2},
+		{`match ([2, 2]) { [1, x] => x, _ => 0 }`, 0},
+		{"struct Point { x, y }; match (Point(0, 5)) { Point{x: 1} => 1, Point{x: 0, y: y} => y }", 5},
+		{"struct A { x }; struct B { x }; match (B(1)) { A{x: x} => 1, B{x: x} => 2 }", 2},
+		{`match ({"x": 1, "y": 2}) { {"y": y, "x": x} => x - y }`, -1},
+		{`match ({"x": 1, "y": 2, "z": 3}) { {"x": x} => x }`, 1},
+		{`match ({"x": 1}) { {"x": 2} => 2, {"z": z} => z, _ => 0 }`, 0},
+		{`match ({"x": [].pop()}) { {"x": x} => 1, _ => 0 }`, 1},
+		{`match ({1: "one"}) { {"1": x} => x, _ => 0 }`, 0},
+		{`match (1) { {"x": x} => x, _ => 0 }`, 0},
+		{`struct Point { x, y }; match (Point(1, 2)) { {"x": x} => x, _ => 0 }`, 0},
+		{`match (try { throw "boom" } catch (e) { e }) { {"message": m} => m, _ => 0 }`, 0},
+		{`match (1) { x => { let y = x + 1; y * 2 } }`, 4},
+		{"let x = 1; let r = match (2) { x => x }; x * 10 + r", 12},
+		{"let x = 1; match (2) { [x] => x, _ => 0 }; x", 1},
+		{"let f = func(v) { match (v) { 0 => { return 10 } _ => 1 }; 2 }; f(0) + f(1)", 12},
+		{"struct P { x }; match ([P(1), 2]) { [P{x: 1}, 2] => true, _ => false }", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("input: %s", tt.input)
+		}
+	}
+
+	errorTests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`match (3) { 1 => 1, 2 => 2 }`, "match has no arm that matches 3"},
+		{`match ([1, "a"]) { [] => 1 }`, "match has no arm that matches [1, a]"},
+		{`match (undefined) { _ => 1 }`, "identifier not found: undefined"},
+		{`match (1) { Point{x: x} => x }`, "identifier not found: Point"},
+		{`let Point = 1; match (1) { Point{x: x} => x }`, "not a struct type INTEGER"},
+		{`struct Point { x, y }; match (1) { Point{z: z} => z }`, "Point has no field z"},
+		{`match (1) { x => x + undefined }`, "identifier not found: undefined"},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expectedMessage {
+			t.Errorf("%s: expected the error %q, got=%T (%+v)", tt.input, tt.expectedMessage, evaluated, evaluated)
+		}
+	}
+}
+
+// budgetExceeded is what the hook of FuzzEval panics with to stop a program that runs for too long.
+type budgetExceeded struct{}
+
+// budgetHook stops the evaluation of a program once it evaluated a number of nodes, or once the values bound in the
+// environment of a node grew too big, as a program without loops can still recurse forever, or double a string till
+// it runs out of memory.
+type budgetHook struct {
+	steps, maxSteps int
+	maxSize         int
+}
+
+func (h *budgetHook) BeforeNode(node ast.Node, env *object.Environment) {
+	h.steps++
+	if h.steps > h.maxSteps {
+		panic(budgetExceeded{})
+	}
+	for ; env != nil; env = env.Outer() {
+		for _, name := range env.Names() {
+			value, _ := env.Get(name)
+			if valueSize(value, h.maxSize) > h.maxSize {
+				panic(budgetExceeded{})
+			}
+		}
+	}
+}
+
+// valueSize returns roughly the memory a value takes, counting at most max for it.
+func valueSize(value object.Object, max int) int {
+	switch value := value.(type) {
+	case *object.String:
+		return len(value.Value)
+	case *object.Array:
+		size := len(value.Elements)
+		for _, el := range value.Elements {
+			if size > max {
+				break
+			}
+			size += valueSize(el, max-size)
+		}
+		return size
+	case *object.Struct:
+		size := len(value.Values)
+		for _, field := range value.Values {
+			if size > max {
+				break
+			}
+			size += valueSize(field, max-size)
+		}
+		return size
+	default:
+		return 1
+	}
+}
+
+// FuzzEval checks that evaluating a program never panics, within a budget of steps.
+func FuzzEval(f *testing.F) {
+	seeds := []string{
+		"let f = func(a, b) { a + b }; f(1)",
+		"let f = func(n) { if (n < 1) { 0 } else { n + f(n - 1) } }; f(100)",
+		"let xs = [1, 2]; xs.push(xs); assertEqual(xs, [1, 2, xs]); xs",
+		"let f = func(s) { f(s + s) }; f(\"a\")",
+		"try { throw [1, 2] } catch (e) { e.value } finally { 3 }",
+		"len == len",
+		`let s = "a".upper; [s == s, s != s, assertEqual(s, s)]`,
+		"receive(channel())",
+	}
+	paths, err := filepath.Glob(filepath.Join("..", "conformance", "testdata", "*.yz"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		seeds = append(seeds, string(src))
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors) != 0 {
+			return
+		}
+		// Any file could be imported, like /dev/zero, and the step budget can not stop a spawned function or a
+		// select that waits forever.
+		skipped := false
+		ast.Inspect(program, func(node ast.Node) bool {
+			switch node.(type) {
+			case *ast.ImportStatementNode, *ast.SpawnExpressionNode, *ast.SelectExpressionNode:
+				skipped = true
+			}
+			return true
+		})
+		if skipped {
+			return
+		}
+
+		interpreter := New()
+		for name := range channelBuiltins() {
+			interpreter.RemoveBuiltin(name)
+		}
+		// What programs print is inspected, but not written.
+		interpreter.SetBuiltin("print", func(args ...object.Object) object.Object {
+			for _, arg := range args {
+				arg.Inspect()
+			}
+			return NULL
+		})
+		interpreter.Hook = &budgetHook{maxSteps: 10000, maxSize: 1 << 16}
+
+		// The step budget can not stop a built-in function that blocks, so the evaluation has a deadline too.
+		done := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				r := recover()
+				if _, ok := r.(budgetExceeded); ok {
+					r = nil
+				}
+				done <- r
+			}()
+			result := interpreter.Eval(program, object.NewEnvironment())
+			if result != nil {
+				result.Inspect()
+			}
+		}()
+
+		select {
+		case r := <-done:
+			if r != nil {
+				t.Fatalf("evaluating %q panicked: %v", input, r)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("evaluating %q did not finish in 10s", input)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/object"
+)
+
+/* Exceptions
+- `throw <expression>` turns its value into an object.Error, which stops the evaluation just like any other error.
+- `try { ... } catch (e) { ... } finally { ... }` stops an object.Error that comes out of its try block, and evaluates the
+	catch block with e bound to an object.ErrorValue, which is a normal value that has the error's message and stack.
+- The finally block is always evaluated after the try and catch blocks. The try expression evaluates to the value of the
+	try or catch block, unless the finally block itself errors or returns.
+- The stack of an error is recorded by the first statement that the error comes out of, because at that point the frames
+	of the interpreter are still the frames of the calls that were being evaluated when the error happened.
+*/
+
+// frame is a type for representing a call that is being evaluated.
+type frame struct {
+	id   int                 // unique among the frames of the interpreter
+	name string              // name of the function that was called
+	line int                 // line of the statement of the function that is being evaluated
+	env  *object.Environment // environment of the statement of the function that is being evaluated
+}
+
+func (in *Interpreter) pushFrame(name string, env *object.Environment) {
+	in.frameIDs++
+	in.frames = append(in.frames, &frame{id: in.frameIDs, name: name, env: env})
+}
+
+func (in *Interpreter) popFrame() {
+	in.frames = in.frames[:len(in.frames)-1]
+}
+
+// programName returns the name of the frame of the program that is being evaluated.
+func (in *Interpreter) programName() string {
+	if len(in.loading) > 0 {
+		return in.loading[len(in.loading)-1].Name
+	}
+	return "<program>"
+}
+
+func functionName(fnObj *object.Function) string {
+	if fnObj.Name == "" {
+		return "<anonymous>"
+	}
+	return fnObj.Name
+}
+
+// stackTrace returns the frames of the interpreter as lines of a stack trace, the innermost frame is first.
+func (in *Interpreter) stackTrace() []string {
+	stack := []string{}
+	for idx := len(in.frames) - 1; idx >= 0; idx-- {
+		stack = append(stack, fmt.Sprintf("at %s (line %d)", in.frames[idx].name, in.frames[idx].line))
+	}
+	return stack
+}
+
+// evaluateStatement evaluates a statement of a program or a block, keeping track of the line that is being evaluated.
+func (in *Interpreter) evaluateStatement(stmtNode ast.StatementNode, env *object.Environment) object.Object {
+	if len(in.frames) > 0 {
+		in.frames[len(in.frames)-1].line, _ = stmtNode.Position()
+		in.frames[len(in.frames)-1].env = env
+	}
+
+	result := in.Eval(stmtNode, env)
+
+	if errObj, ok := result.(*object.Error); ok && errObj.Stack == nil {
+		errObj.Stack = in.stackTrace()
+	}
+
+	return result
+}
+
+func throwValue(value object.Object) *object.Error {
+	switch value := value.(type) {
+	case *object.ErrorValue: // Re-throwing a caught error keeps the stack of the place where it was first thrown.
+		return &object.Error{Message: value.Message, Stack: value.Stack, Thrown: true}
+
+	case *object.String:
+		return &object.Error{Message: value.Value, Thrown: true}
+
+	default:
+		return &object.Error{Message: value.Inspect(), Thrown: true}
+	}
+}
+
+func (in *Interpreter) evaluateTryExpression(node *ast.TryExpressionNode, env *object.Environment) object.Object {
+	result := in.Eval(node.Block, env)
+
+	if errObj, ok := result.(*object.Error); ok && node.Catch != nil {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		if node.CatchParam != nil {
+			catchEnv.Set(node.CatchParam.Name, &object.ErrorValue{Message: errObj.Message, Stack: errObj.Stack})
+		}
+		result = in.Eval(node.Catch, catchEnv)
+	}
+
+	if node.Finally != nil {
+		finallyResult := in.Eval(node.Finally, env)
+		if isError(finallyResult) {
+			return finallyResult
+		}
+		if _, ok := finallyResult.(*object.ReturnValue); ok {
+			return finallyResult
+		}
+	}
+
+	return result
+}
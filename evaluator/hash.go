@@ -0,0 +1,47 @@
+package evaluator
+
+import (
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/object"
+)
+
+/* Hashes
+- `{"one": 1, 2: "two"}` makes an object.Hash. The keys can be integers, booleans and strings, and are evaluated like
+	the values are, so `{name: 1}` has the value of name as its key.
+- A key given twice gets the last of its values, but keeps the place of its first one in the order of the keys.
+- `h["one"]` evaluates to the value of a key, or null when the hash does not have the key.
+- Two hashes are equal when they have the same keys with equal values, like structs are compared by their fields.
+- A hash cannot be changed after it is made.
+*/
+
+func (in *Interpreter) evaluateHashLiteral(node *ast.HashLiteralNode, env *object.Environment) object.Object {
+	hash := object.NewHash()
+	for idx, keyNode := range node.Keys {
+		key := in.Eval(keyNode, env)
+		if isErrorOrReturn(key) {
+			return key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := in.Eval(node.Values[idx], env)
+		if isErrorOrReturn(value) {
+			return value
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash
+}
+
+func evaluateHashIndexExpression(hash *object.Hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+	if value, ok := hash.Get(key); ok {
+		return value
+	}
+	return NULL
+}
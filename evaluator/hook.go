@@ -0,0 +1,45 @@
+package evaluator
+
+import (
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/object"
+)
+
+// Hook is an interface type for watching an interpreter evaluate a program, which tools like debuggers use.
+type Hook interface {
+	// BeforeNode is called before a statement or expression node is evaluated, with the environment it is evaluated in.
+	BeforeNode(node ast.Node, env *object.Environment)
+}
+
+// FileHook is an interface type for hooks that are also told about every file the interpreter evaluates, the
+// imported files included, which tools like coverage use to find out which file a node is in.
+type FileHook interface {
+	Hook
+	// BeforeFile is called before the program parsed from the file at the absolute path is evaluated.
+	BeforeFile(path string, program *ast.Program)
+}
+
+// beforeFile calls the hook of the interpreter if it is a FileHook.
+func (in *Interpreter) beforeFile(path string, program *ast.Program) {
+	if hook, ok := in.Hook.(FileHook); ok {
+		hook.BeforeFile(path, program)
+	}
+}
+
+// StackFrame is a type for representing a call that is being evaluated, the evaluation of a program is a call too.
+type StackFrame struct {
+	ID   int                 // unique among the frames of the interpreter, a recursive call gets a new ID every time
+	Name string              // name of the function, or of the program
+	Line int                 // line of the statement that is being evaluated
+	Env  *object.Environment // environment of the statement that is being evaluated
+}
+
+// CallStack returns the calls that are being evaluated, the innermost one is first.
+func (in *Interpreter) CallStack() []StackFrame {
+	stack := make([]StackFrame, 0, len(in.frames))
+	for idx := len(in.frames) - 1; idx >= 0; idx-- {
+		fr := in.frames[idx]
+		stack = append(stack, StackFrame{ID: fr.id, Name: fr.name, Line: fr.line, Env: fr.env})
+	}
+	return stack
+}
@@ -0,0 +1,127 @@
+package evaluator
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/shksa/yeezy/object"
+)
+
+/* Why an Interpreter?
+- Built-in functions used to live in a single package-level map, so every program evaluated in a process saw the same
+	built-ins and there was no way to take one away or to swap one for a fake.
+- An Interpreter owns its own table of built-in functions, which starts as a copy of the default table.
+- Built-ins can be added, removed or shadowed on one Interpreter without affecting any other Interpreter, which is
+	what sandboxing a program or testing with fakes needs.
+- Bindings in the environment always win over built-ins, so a `let len = ...` in yeezy code shadows the built-in too.
+*/
+
+// Interpreter is a type for representing one instance of the yeezy interpreter and the state it evaluates programs with.
+// It is not safe for concurrent use, goroutines that evaluate programs each need an Interpreter of their own, but they
+// can share a frozen environment, see object.Environment.Freeze.
+type Interpreter struct {
+	// SearchPath is the list of directories in which imported files are looked up when they are not found relative to
+	// the importing file.
+	SearchPath []string
+	// Hook, if it is set, is called before every node the interpreter evaluates.
+	Hook Hook
+
+	builtins map[string]object.BuiltInFunction
+	methods  map[string]map[string]object.BuiltInFunction // methods by the type of the object they are called on
+	modules  map[string]*object.Module                    // imported modules, by the absolute path of their file
+	// modulesMu guards modules, which the interpreters of spawned functions share.
+	modulesMu *sync.Mutex
+	loading   []*object.Module // modules that are being evaluated, the innermost one is last
+	frames    []*frame         // calls that are being evaluated, the innermost one is last
+	frameIDs  int              // number of frames pushed so far, to give each one an ID
+
+	// bound are the names of the builtins that are bound to this interpreter, which are made again for every spawned
+	// interpreter instead of being shared with it.
+	bound map[string]bool
+}
+
+// New returns a pointer to a newly created Interpreter that has all the default built-in functions.
+func New() *Interpreter {
+	in := NewSandboxed()
+	for name, builtInFunc := range defaultBuiltins {
+		in.builtins[name] = builtInFunc
+	}
+	for name, builtInFunc := range in.assertBuiltins() {
+		in.builtins[name] = builtInFunc
+		in.bound[name] = true
+	}
+	for name, builtInFunc := range channelBuiltins() {
+		in.builtins[name] = builtInFunc
+	}
+	for objType, methods := range defaultMethods {
+		for name, method := range methods {
+			in.RegisterMethod(objType, name, method)
+		}
+	}
+	return in
+}
+
+// NewSandboxed returns a pointer to a newly created Interpreter that has no built-in functions or methods at all.
+func NewSandboxed() *Interpreter {
+	return &Interpreter{
+		builtins:  make(map[string]object.BuiltInFunction),
+		bound:     make(map[string]bool),
+		methods:   make(map[string]map[string]object.BuiltInFunction),
+		modules:   make(map[string]*object.Module),
+		modulesMu: &sync.Mutex{},
+	}
+}
+
+// Call calls a yeezy function or a built-in function with arguments, and returns what it returned, or the error that
+// stopped it.
+func (in *Interpreter) Call(fn object.Object, args ...object.Object) object.Object {
+	return in.applyFunction(fn, args)
+}
+
+// SetBuiltin adds a built-in function to the interpreter, shadowing any built-in that has the same name.
+func (in *Interpreter) SetBuiltin(name string, builtInFunc object.BuiltInFunction) {
+	in.builtins[name] = builtInFunc
+	delete(in.bound, name)
+}
+
+// RemoveBuiltin removes a built-in function from the interpreter.
+func (in *Interpreter) RemoveBuiltin(name string) {
+	delete(in.builtins, name)
+	delete(in.bound, name)
+}
+
+// Builtin returns the built-in function of the interpreter that has the given name.
+func (in *Interpreter) Builtin(name string) (object.BuiltInFunction, bool) {
+	builtInFunc, ok := in.builtins[name]
+	return builtInFunc, ok
+}
+
+// BuiltinNames returns the sorted names of all the built-in functions of the interpreter.
+func (in *Interpreter) BuiltinNames() []string {
+	names := make([]string, 0, len(in.builtins))
+	for name := range in.builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterMethod adds a method to all the objects of the given type, ex:- object.STRING.
+// The method is called with the object it is called on as its first argument, followed by the call's arguments.
+func (in *Interpreter) RegisterMethod(objType, name string, method object.BuiltInFunction) {
+	if in.methods[objType] == nil {
+		in.methods[objType] = make(map[string]object.BuiltInFunction)
+	}
+	in.methods[objType][name] = method
+}
+
+// RemoveMethod removes a method from all the objects of the given type.
+func (in *Interpreter) RemoveMethod(objType, name string) {
+	delete(in.methods[objType], name)
+}
+
+// Method returns the method with the given name registered for the given type of objects.
+func (in *Interpreter) Method(objType, name string) (object.BuiltInFunction, bool) {
+	method, ok := in.methods[objType][name]
+	return method, ok
+}
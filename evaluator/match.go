@@ -0,0 +1,118 @@
+package evaluator
+
+import (
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/object"
+)
+
+/* Pattern matching
+- `match (value) { <pattern> => <expression>, ... }` tries the patterns of the arms in order and evaluates to the
+	expression of the first arm whose pattern matches the value. No matching arm is an error.
+- Integer, string and boolean literals match the values equal to them, `_` matches anything, and any other identifier
+	matches anything and binds it to its name.
+- `[a, b]` matches the arrays of exactly as many elements, with every element matching its pattern.
+- `Point{x: 0, y: y}` matches the structs made by Point whose listed fields match their patterns, the fields that are
+	not listed can have any value.
+- `{"k": v}` matches the hashes that have the key "k" with a value matching v, the keys that are not listed can have any
+	value. The keys of hash patterns are strings.
+- Every arm gets its own environment enclosed by the one the match is in, so the names bound by a pattern are only
+	visible in the expression of its arm, and an arm that does not match leaves nothing bound.
+*/
+
+func (in *Interpreter) evaluateMatchExpression(node *ast.MatchExpressionNode, env *object.Environment) object.Object {
+	value := in.Eval(node.Value, env)
+	if isErrorOrReturn(value) {
+		return value
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		matched, err := in.matchPattern(arm.Pattern, value, env, armEnv)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		if arm.Body != nil {
+			return in.Eval(arm.Body, armEnv)
+		}
+		return in.Eval(arm.Value, armEnv)
+	}
+
+	return newError("match has no arm that matches %s", value.Inspect())
+}
+
+// matchPattern tells whether the value matches the pattern and binds the names in the pattern in armEnv. The types of
+// struct patterns are looked up in env, so that they are not shadowed by the names bound before them. The error is
+// non-nil when the pattern cannot be matched against anything.
+func (in *Interpreter) matchPattern(pattern ast.ExpressionNode, value object.Object, env, armEnv *object.Environment) (bool, object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.IdentifierNode:
+		if pattern.Name != "_" {
+			armEnv.Set(pattern.Name, value)
+		}
+		return true, nil
+
+	case *ast.ArrayLiteralNode:
+		array, ok := value.(*object.Array)
+		if !ok || len(array.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+		for idx, element := range pattern.Elements {
+			if matched, err := in.matchPattern(element, array.Elements[idx], env, armEnv); !matched || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case *ast.StructLiteralNode:
+		typeValue := in.Eval(pattern.Type, env)
+		if isErrorOrReturn(typeValue) {
+			return false, typeValue
+		}
+		structType, ok := typeValue.(*object.StructType)
+		if !ok {
+			return false, newError("not a struct type %s", typeValue.Type())
+		}
+		fieldIdxs := make([]int, len(pattern.Fields))
+		for idx, field := range pattern.Fields {
+			if fieldIdxs[idx], ok = structType.FieldIndex(field.Name); !ok {
+				return false, newError("%s has no field %s", structType.Name, field.Name)
+			}
+		}
+		structObj, ok := value.(*object.Struct)
+		if !ok || structObj.StructType != structType {
+			return false, nil
+		}
+		for idx, fieldPattern := range pattern.Values {
+			if matched, err := in.matchPattern(fieldPattern, structObj.Values[fieldIdxs[idx]], env, armEnv); !matched || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case *ast.HashPatternNode:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+		for idx, key := range pattern.Keys {
+			keyValue, ok := hash.Get(&object.String{Value: key.Value})
+			if !ok {
+				return false, nil
+			}
+			if matched, err := in.matchPattern(pattern.Values[idx], keyValue, env, armEnv); !matched || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+
+	default: // the literals
+		literal := in.Eval(pattern, env)
+		if isErrorOrReturn(literal) {
+			return false, literal
+		}
+		return objectsEqual(literal, value), nil
+	}
+}
@@ -0,0 +1,152 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/shksa/yeezy/object"
+)
+
+/* Methods
+- `"abc".upper()` is a call expression whose function is the member expression `"abc".upper`.
+- Evaluating the member expression looks up the method "upper" in the interpreter's method table for the STRING type,
+	and returns a built-in function that has the string bound as its first argument.
+- So a method is just a built-in function that receives the object it is called on as args[0].
+*/
+
+// defaultMethods is the table of methods, by object type, every new Interpreter starts with.
+var defaultMethods = map[string]map[string]object.BuiltInFunction{
+	object.STRING: {
+		"len": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			return &object.Integer{Value: int64(len(args[0].(*object.String).Value))}
+		},
+		"upper": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToUpper(args[0].(*object.String).Value)}
+		},
+		"lower": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToLower(args[0].(*object.String).Value)}
+		},
+		"trim": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			return &object.String{Value: strings.TrimSpace(args[0].(*object.String).Value)}
+		},
+		"contains": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 1, object.STRING); err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.Contains(args[0].(*object.String).Value, args[1].(*object.String).Value))
+		},
+		"split": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 1, object.STRING); err != nil {
+				return err
+			}
+			parts := strings.Split(args[0].(*object.String).Value, args[1].(*object.String).Value)
+			elements := make([]object.Object, len(parts))
+			for idx, part := range parts {
+				elements[idx] = &object.String{Value: part}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	object.ARRAY: {
+		"len": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			return &object.Integer{Value: int64(len(args[0].(*object.Array).Elements))}
+		},
+		"push": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 1, ""); err != nil {
+				return err
+			}
+			array := args[0].(*object.Array)
+			array.Elements = append(array.Elements, args[1])
+			return array
+		},
+		"pop": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			array := args[0].(*object.Array)
+			if len(array.Elements) == 0 {
+				return NULL
+			}
+			last := array.Elements[len(array.Elements)-1]
+			array.Elements = array.Elements[:len(array.Elements)-1]
+			return last
+		},
+		"join": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 1, object.STRING); err != nil {
+				return err
+			}
+			parts := []string{}
+			for _, el := range args[0].(*object.Array).Elements {
+				parts = append(parts, el.Inspect())
+			}
+			return &object.String{Value: strings.Join(parts, args[1].(*object.String).Value)}
+		},
+	},
+	object.HASH: {
+		"len": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			return &object.Integer{Value: int64(len(args[0].(*object.Hash).Keys))}
+		},
+		"keys": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			hash := args[0].(*object.Hash)
+			keys := make([]object.Object, len(hash.Keys))
+			for idx, key := range hash.Keys {
+				keys[idx] = hash.Pairs[key].Key
+			}
+			return &object.Array{Elements: keys}
+		},
+		"values": func(args ...object.Object) object.Object {
+			if err := checkMethodArgs(args, 0); err != nil {
+				return err
+			}
+			hash := args[0].(*object.Hash)
+			values := make([]object.Object, len(hash.Keys))
+			for idx, key := range hash.Keys {
+				values[idx] = hash.Pairs[key].Value
+			}
+			return &object.Array{Elements: values}
+		},
+	},
+}
+
+// checkMethodArgs checks the arguments a method is called with, not counting the object it is called on.
+// An empty type in argTypes accepts an argument of any type.
+func checkMethodArgs(args []object.Object, want int, argTypes ...string) *object.Error {
+	if len(args)-1 != want {
+		return newError("Wrong number of arguments. want=%d, got=%d", want, len(args)-1)
+	}
+
+	for idx, argType := range argTypes {
+		if argType != "" && args[idx+1].Type() != argType {
+			return newError("argument %d must be %s. got=%s", idx+1, argType, args[idx+1].Type())
+		}
+	}
+
+	return nil
+}
+
+// bindMethod returns a built-in function that calls the method with the receiver as its first argument.
+func bindMethod(method object.BuiltInFunction, receiver object.Object) object.BuiltInFunction {
+	return func(args ...object.Object) object.Object {
+		return method(append([]object.Object{receiver}, args...)...)
+	}
+}
@@ -0,0 +1,147 @@
+package evaluator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+)
+
+/* Modules
+- `import "path/to/lib.yz" as lib` evaluates the file in a fresh environment and binds an object.Module to lib.
+- Only the bindings declared with `export let` are visible from outside the module, as lib.<name>. The parser only allows
+	export at the top level of a module.
+- A relative import path is first looked up next to the file that imports it, then in every directory of the
+	interpreter's SearchPath, in order.
+- Every file is evaluated at most once per interpreter, later imports of the same file get the cached module.
+- The files that are being evaluated right now are kept in a stack, so a file that imports itself through a chain of
+	imports is reported as an import cycle instead of recursing forever.
+*/
+
+// EvalFile evaluates the program parsed from the file at filePath, so that the imports in it are resolved relative to it.
+func (in *Interpreter) EvalFile(filePath string, program *ast.Program, env *object.Environment) object.Object {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return newError("%s", err)
+	}
+
+	in.loading = append(in.loading, newModule(absPath))
+	defer func() { in.loading = in.loading[:len(in.loading)-1] }()
+	in.beforeFile(absPath, program)
+
+	return in.Eval(program, env)
+}
+
+func newModule(absPath string) *object.Module {
+	name := strings.TrimSuffix(filepath.Base(absPath), filepath.Ext(absPath))
+	return &object.Module{Name: name, Path: absPath, Exports: make(map[string]object.Object)}
+}
+
+func (in *Interpreter) evaluateImportStatement(node *ast.ImportStatementNode, env *object.Environment) object.Object {
+	module := in.importModule(node.Path.Value)
+	if isError(module) {
+		return module
+	}
+
+	name := module.(*object.Module).Name
+	if node.Alias != nil {
+		name = node.Alias.Name
+	}
+	return bind(env, name, module, false)
+}
+
+func (in *Interpreter) evaluateExportStatement(node *ast.ExportStatementNode, env *object.Environment) object.Object {
+	if result := in.Eval(node.Statement, env); isErrorOrReturn(result) {
+		return result
+	}
+
+	if len(in.loading) > 0 {
+		value, _ := env.Get(node.Statement.Iden.Name)
+		in.loading[len(in.loading)-1].Exports[node.Statement.Iden.Name] = value
+	}
+
+	return nil
+}
+
+func (in *Interpreter) importModule(importPath string) object.Object {
+	absPath, ok := in.resolveImportPath(importPath)
+	if !ok {
+		return newError("module not found: %q", importPath)
+	}
+
+	in.modulesMu.Lock()
+	module, ok := in.modules[absPath]
+	in.modulesMu.Unlock()
+	if ok {
+		return module
+	}
+
+	for idx, loadingModule := range in.loading {
+		if loadingModule.Path == absPath {
+			cycle := []string{}
+			for _, m := range in.loading[idx:] {
+				cycle = append(cycle, m.Path)
+			}
+			cycle = append(cycle, absPath)
+			return newError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	fileContent, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return newError("%s", err)
+	}
+
+	p := parser.New(lexer.New(string(fileContent)))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		return newError("parse errors in module %q: %s", importPath, strings.Join(p.Errors, "; "))
+	}
+
+	module = newModule(absPath)
+	in.loading = append(in.loading, module)
+	in.beforeFile(absPath, program)
+	evaluated := in.Eval(program, object.NewEnvironment())
+	in.loading = in.loading[:len(in.loading)-1]
+
+	if isError(evaluated) {
+		return evaluated
+	}
+
+	in.modulesMu.Lock()
+	in.modules[absPath] = module
+	in.modulesMu.Unlock()
+	return module
+}
+
+// resolveImportPath returns the absolute path of the file an import path refers to.
+func (in *Interpreter) resolveImportPath(importPath string) (string, bool) {
+	if filepath.IsAbs(importPath) {
+		return importPath, fileExists(importPath)
+	}
+
+	dirs := []string{"."}
+	if len(in.loading) > 0 {
+		dirs[0] = filepath.Dir(in.loading[len(in.loading)-1].Path)
+	}
+	dirs = append(dirs, in.SearchPath...)
+
+	for _, dir := range dirs {
+		candidate, err := filepath.Abs(filepath.Join(dir, importPath))
+		if err == nil && fileExists(candidate) {
+			return candidate, true
+		}
+	}
+
+	return "", false
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/object"
+)
+
+/* Structs
+- `struct Point { x, y }` binds Point to an object.StructType, which knows the names of the fields in their order.
+- Calling the type, `Point(1, 2)`, makes an object.Struct with the arguments as the values of the fields in that order,
+	and the literal `Point{x: 1, y: 2}` makes one with the fields given by name. Both need a value for every field.
+- `p.x` evaluates to the value of a field and `p.x = 3` changes it in place, like `push` changes an array, so every
+	binding of the same struct sees the change.
+- Two structs are equal when they were made by the same struct statement and their fields are equal, like arrays are
+	compared by their elements.
+*/
+
+func (in *Interpreter) evaluateStructStatement(node *ast.StructStatementNode, env *object.Environment) object.Object {
+	structType := &object.StructType{Name: node.Name.Name}
+	for _, field := range node.Fields {
+		structType.Fields = append(structType.Fields, field.Name)
+	}
+	return bind(env, node.Name.Name, structType, false)
+}
+
+// newStruct makes a struct of the type with the arguments of a call to the type as the values of its fields.
+func newStruct(structType *object.StructType, args []object.Object) object.Object {
+	if len(args) != len(structType.Fields) {
+		return newError("%s takes %d arguments, but is called with %d", structType.Name, len(structType.Fields), len(args))
+	}
+	return &object.Struct{StructType: structType, Values: append([]object.Object(nil), args...)}
+}
+
+func (in *Interpreter) evaluateStructLiteral(node *ast.StructLiteralNode, env *object.Environment) object.Object {
+	value := in.Eval(node.Type, env)
+	if isErrorOrReturn(value) {
+		return value
+	}
+	structType, ok := value.(*object.StructType)
+	if !ok {
+		return newError("not a struct type %s", value.Type())
+	}
+
+	values := make([]object.Object, len(structType.Fields))
+	for idx, field := range node.Fields {
+		fieldIdx, ok := structType.FieldIndex(field.Name)
+		if !ok {
+			return newError("%s has no field %s", structType.Name, field.Name)
+		}
+		value := in.Eval(node.Values[idx], env)
+		if isErrorOrReturn(value) {
+			return value
+		}
+		values[fieldIdx] = value
+	}
+	for idx, value := range values {
+		if value == nil {
+			return newError("%s{...} has no value for the field %s", structType.Name, structType.Fields[idx])
+		}
+	}
+
+	return &object.Struct{StructType: structType, Values: values}
+}
+
+func (in *Interpreter) evaluateAssignStatement(node *ast.AssignStatementNode, env *object.Environment) object.Object {
+	obj := in.Eval(node.Target.Object, env)
+	if isErrorOrReturn(obj) {
+		return obj
+	}
+	structObj, ok := obj.(*object.Struct)
+	if !ok {
+		return newError("cannot assign to the field %s of %s, only to the fields of a STRUCT", node.Target.Property.Name, obj.Type())
+	}
+	fieldIdx, ok := structObj.StructType.FieldIndex(node.Target.Property.Name)
+	if !ok {
+		return newError("%s has no field %s", structObj.StructType.Name, node.Target.Property.Name)
+	}
+
+	value := in.Eval(node.Value, env)
+	if isErrorOrReturn(value) {
+		return value
+	}
+	structObj.Values[fieldIdx] = value
+
+	return nil
+}
@@ -0,0 +1,610 @@
+// Package format prints yeezy programs in their canonical form, the way `yeezy fmt` does.
+//
+// The canonical form puts one statement on each line, indents blocks with 2 spaces, keeps only the parentheses that
+// the precedence of the operators needs, and keeps the comments and the blank lines between statements. An expression
+// with comments in it is continued on the next line after each of them, one level deeper.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/parser"
+	"github.com/shksa/yeezy/token"
+)
+
+// INDENT is the indentation of one level of blocks.
+const INDENT = "  "
+
+// Source formats the source code of a program. It returns an error listing the parse errors if the program has any,
+// as only a program that parses can be formatted.
+func Source(src string) (string, error) {
+	shebang := ""
+	if strings.HasPrefix(src, "#!") {
+		shebang = src
+		if idx := strings.IndexByte(src, '\n'); idx >= 0 {
+			shebang = src[:idx]
+		}
+		shebang = strings.TrimRight(shebang, "\r") + "\n"
+	}
+
+	l := lexer.New(src)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		return "", errors.New("parse errors:\n\t" + strings.Join(p.Errors, "\n\t"))
+	}
+
+	return shebang + Program(program, l.Comments()), nil
+}
+
+// Program returns the canonical source code of a program, with the comments put back at the lines they were on.
+// comments are the comments of the program's source code, as returned by the lexer that read it.
+func Program(program *ast.Program, comments []token.Token) string {
+	pr := &printer{comments: comments, atBlockStart: true}
+	pr.printStatements(program.Statements)
+	pr.printCommentsBefore(-1)
+	return pr.out.String()
+}
+
+// printer is a type for holding the state of formatting one program.
+type printer struct {
+	out          bytes.Buffer
+	depth        int           // how many blocks deep the printer is
+	comments     []token.Token // the comments that are not printed yet
+	lastLine     int           // the source line of the last statement, expression or comment printed
+	atBlockStart bool          // nothing has been printed in the current block yet
+	end          token.Token   // the token that ends the block being printed, the comments after it are not in it
+	continued    bool          // the current statement is continued on another line after a comment in it
+}
+
+func (pr *printer) newLine() {
+	pr.out.WriteString("\n" + strings.Repeat(INDENT, pr.depth))
+}
+
+// beginLine starts the line for a statement or a comment that is at the given line in the source code.
+// One blank line is kept if there were blank lines before it in the source code.
+func (pr *printer) beginLine(line int) {
+	if pr.out.Len() > 0 || pr.depth > 0 {
+		if !pr.atBlockStart && line > pr.lastLine+1 {
+			pr.out.WriteString("\n")
+		}
+		pr.newLine()
+	}
+	pr.atBlockStart = false
+	pr.lastLine = line
+}
+
+// printCommentsBefore prints the comments before the given line, each on its own line. -1 prints all of them.
+func (pr *printer) printCommentsBefore(line int) {
+	for len(pr.comments) > 0 && (line == -1 || pr.comments[0].Line < line) {
+		pr.beginLine(pr.comments[0].Line)
+		pr.out.WriteString(pr.comments[0].Literal)
+		pr.comments = pr.comments[1:]
+	}
+	if line == -1 && pr.out.Len() > 0 {
+		pr.out.WriteString("\n")
+	}
+}
+
+func (pr *printer) printStatements(stmts []ast.StatementNode) {
+	for idx, stmt := range stmts {
+		line, _ := stmt.Position()
+		pr.printCommentsBefore(line)
+		pr.beginLine(line)
+		pr.keepDepth(func() { pr.printStatement(stmt) })
+
+		// Without a semicolon, a statement that starts with one of these tokens would continue the previous one.
+		if idx+1 < len(stmts) && strings.ContainsAny(startOfStatement(stmts[idx+1]), "([-{") {
+			pr.out.WriteString(";")
+		}
+
+		pr.lastLine = lastLine(stmt)
+		pr.printTrailingComment()
+	}
+}
+
+// printTrailingComment prints the comment on the last line printed so far after what is printed on it, unless the
+// comment comes after the end of the block being printed.
+func (pr *printer) printTrailingComment() {
+	if len(pr.comments) == 0 || pr.comments[0].Line != pr.lastLine {
+		return
+	}
+	if pr.end.Line == pr.lastLine && pr.comments[0].Column > pr.end.Column {
+		return
+	}
+	pr.out.WriteString(" " + pr.comments[0].Literal)
+	pr.comments = pr.comments[1:]
+}
+
+// printCommentsInside prints the comments before the given line that are in the middle of the statement being
+// printed, after what is printed on their line or on their own lines, and tells whether there were any. The rest of
+// the statement is indented one level deeper.
+func (pr *printer) printCommentsInside(line int) bool {
+	if len(pr.comments) == 0 || pr.comments[0].Line >= line {
+		return false
+	}
+	if !pr.continued {
+		pr.continued = true
+		pr.depth++
+	}
+	for len(pr.comments) > 0 && pr.comments[0].Line < line {
+		pr.trimSpaces()
+		if pr.comments[0].Line > pr.lastLine {
+			pr.newLine()
+		} else {
+			pr.out.WriteString(" ")
+		}
+		pr.out.WriteString(pr.comments[0].Literal)
+		pr.lastLine = pr.comments[0].Line
+		pr.comments = pr.comments[1:]
+	}
+	return true
+}
+
+// printSeparator prints sep before what starts at the given line, or continues the statement on a new line instead if
+// there are comments before it.
+func (pr *printer) printSeparator(line int, sep string) {
+	if pr.printCommentsInside(line) {
+		pr.newLine()
+	} else {
+		pr.out.WriteString(sep)
+	}
+	if line > pr.lastLine {
+		pr.lastLine = line
+	}
+}
+
+// printEnd prints the token that closes a list, on its own line at the indentation of the statement if there are
+// comments before it.
+func (pr *printer) printEnd(end token.Token) {
+	if pr.printCommentsInside(end.Line) {
+		pr.depth--
+		pr.newLine()
+		pr.depth++
+	}
+	pr.out.WriteString(end.Literal)
+	if end.Line > pr.lastLine {
+		pr.lastLine = end.Line
+	}
+}
+
+// trimSpaces removes the spaces at the end of what is printed so far, like the one after an operator that is followed
+// by a comment.
+func (pr *printer) trimSpaces() {
+	printed := pr.out.Bytes()
+	pr.out.Truncate(len(bytes.TrimRight(printed, " ")))
+}
+
+// keepDepth calls print, and goes back to the current indentation if what it printed was continued after a comment.
+func (pr *printer) keepDepth(print func()) {
+	continued := pr.continued
+	pr.continued = false
+	print()
+	if pr.continued {
+		pr.depth--
+	}
+	pr.continued = continued
+}
+
+func (pr *printer) printStatement(stmt ast.StatementNode) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatementNode:
+		pr.out.WriteString(stmt.TokenLiteral() + " " + stmt.Iden.Name + " = ")
+		pr.printExpression(stmt.Value)
+	case *ast.ReturnStatementNode:
+		pr.out.WriteString("return ")
+		pr.printExpression(stmt.ReturnValue)
+	case *ast.ExpressionStatementNode:
+		pr.printExpression(stmt.Expression)
+	case *ast.ImportStatementNode:
+		pr.out.WriteString(`import "` + stmt.Path.Value + `"`)
+		if stmt.Alias != nil {
+			pr.out.WriteString(" as " + stmt.Alias.Name)
+		}
+	case *ast.ExportStatementNode:
+		pr.out.WriteString("export ")
+		pr.printStatement(stmt.Statement)
+	case *ast.ThrowStatementNode:
+		pr.out.WriteString("throw ")
+		pr.printExpression(stmt.Value)
+	case *ast.StructStatementNode:
+		pr.out.WriteString("struct " + stmt.Name.Name + " {")
+		for idx, field := range stmt.Fields {
+			if idx > 0 {
+				pr.out.WriteString(",")
+			}
+			pr.printSeparator(field.Token.Line, " ")
+			pr.out.WriteString(field.Name)
+		}
+		if len(stmt.Fields) > 0 && (len(pr.comments) == 0 || pr.comments[0].Line >= stmt.EndToken.Line) {
+			pr.out.WriteString(" ")
+		}
+		pr.printEnd(stmt.EndToken)
+	case *ast.AssignStatementNode:
+		pr.printExpression(stmt.Target)
+		pr.out.WriteString(" = ")
+		pr.printExpression(stmt.Value)
+	case *ast.BlockStatementNode:
+		pr.printBlock(stmt)
+	default:
+		pr.out.WriteString(stmt.String())
+	}
+}
+
+// printBlock prints a block with its statements indented one level deeper, or {} for a block with nothing in it.
+func (pr *printer) printBlock(block *ast.BlockStatementNode) {
+	if len(block.Statements) == 0 && (len(pr.comments) == 0 || pr.comments[0].Line >= block.EndToken.Line) {
+		pr.out.WriteString("{}")
+		return
+	}
+
+	end := pr.end
+	pr.end = block.EndToken
+	pr.out.WriteString("{")
+	pr.depth++
+	pr.atBlockStart = true
+	pr.printStatements(block.Statements)
+	pr.printCommentsBefore(block.EndToken.Line)
+	pr.depth--
+	pr.newLine()
+	pr.out.WriteString("}")
+	pr.lastLine = block.EndToken.Line
+	pr.end = end
+}
+
+func (pr *printer) printExpression(expr ast.ExpressionNode) {
+	pr.printSeparator(firstLine(expr), "")
+
+	switch expr := expr.(type) {
+	case *ast.StringLiteralNode:
+		pr.out.WriteString(`"` + expr.Value + `"`)
+	case *ast.PrefixExpressionNode:
+		pr.out.WriteString(expr.Operator)
+		pr.printOperand(expr.Right, precedence(expr) > precedence(expr.Right))
+	case *ast.InfixExpressionNode:
+		pr.printOperand(expr.Left, precedence(expr) > precedence(expr.Left))
+		pr.out.WriteString(" " + expr.Operator + " ")
+		pr.printOperand(expr.Right, precedence(expr) >= precedence(expr.Right))
+	case *ast.CallExpressionNode:
+		pr.printOperand(expr.Function, precedence(expr) > precedence(expr.Function))
+		pr.out.WriteString("(")
+		pr.printExpressionList(expr.Arguments)
+		pr.printEnd(expr.EndToken)
+	case *ast.IndexExpressionNode:
+		pr.printOperand(expr.Left, precedence(expr) > precedence(expr.Left))
+		pr.out.WriteString("[")
+		pr.printExpression(expr.Index)
+		pr.printEnd(expr.EndToken)
+	case *ast.MemberExpressionNode:
+		pr.printOperand(expr.Object, precedence(expr) > precedence(expr.Object))
+		pr.out.WriteString("." + expr.Property.Name)
+	case *ast.PropagateExpressionNode:
+		pr.printOperand(expr.Value, precedence(expr) > precedence(expr.Value))
+		pr.out.WriteString("?")
+	case *ast.ArrayLiteralNode:
+		pr.out.WriteString("[")
+		pr.printExpressionList(expr.Elements)
+		pr.printEnd(expr.EndToken)
+	case *ast.HashLiteralNode:
+		pr.out.WriteString("{")
+		for idx, key := range expr.Keys {
+			if idx > 0 {
+				pr.out.WriteString(",")
+				pr.printSeparator(firstLine(key), " ")
+			} else {
+				pr.printSeparator(firstLine(key), "")
+			}
+			pr.printExpression(key)
+			pr.out.WriteString(": ")
+			pr.printExpression(expr.Values[idx])
+		}
+		pr.printEnd(expr.EndToken)
+	case *ast.StructLiteralNode:
+		pr.out.WriteString(expr.Type.Name + "{")
+		for idx, field := range expr.Fields {
+			if idx > 0 {
+				pr.out.WriteString(",")
+				pr.printSeparator(field.Token.Line, " ")
+			} else {
+				pr.printSeparator(field.Token.Line, "")
+			}
+			pr.out.WriteString(field.Name + ": ")
+			pr.printExpression(expr.Values[idx])
+		}
+		pr.printEnd(expr.EndToken)
+	case *ast.IfExpressionNode:
+		pr.out.WriteString("if (")
+		pr.printExpression(expr.Condition)
+		pr.out.WriteString(") ")
+		pr.printBlock(expr.Consequence)
+		if expr.Alternative != nil {
+			pr.out.WriteString(" else ")
+			pr.printBlock(expr.Alternative)
+		}
+	case *ast.FunctionLiteralNode:
+		params := []string{}
+		for _, param := range expr.Parameters {
+			params = append(params, param.Name)
+		}
+		pr.out.WriteString("func(" + strings.Join(params, ", ") + ") ")
+		pr.printBlock(expr.Body)
+	case *ast.TryExpressionNode:
+		pr.out.WriteString("try ")
+		pr.printBlock(expr.Block)
+		if expr.Catch != nil {
+			pr.out.WriteString(" catch ")
+			if expr.CatchParam != nil {
+				pr.out.WriteString("(" + expr.CatchParam.Name + ") ")
+			}
+			pr.printBlock(expr.Catch)
+		}
+		if expr.Finally != nil {
+			pr.out.WriteString(" finally ")
+			pr.printBlock(expr.Finally)
+		}
+	case *ast.SpawnExpressionNode:
+		pr.out.WriteString("spawn ")
+		pr.printExpression(expr.Call)
+	case *ast.SelectExpressionNode:
+		pr.printSelect(expr)
+	case *ast.MatchExpressionNode:
+		pr.printMatch(expr)
+	case *ast.HashPatternNode:
+		pr.out.WriteString("{")
+		for idx, key := range expr.Keys {
+			if idx > 0 {
+				pr.out.WriteString(",")
+				pr.printSeparator(key.Token.Line, " ")
+			} else {
+				pr.printSeparator(key.Token.Line, "")
+			}
+			pr.out.WriteString(`"` + key.Value + `": `)
+			pr.printExpression(expr.Values[idx])
+		}
+		pr.printEnd(expr.EndToken)
+	default:
+		pr.out.WriteString(expr.String())
+	}
+}
+
+// printSelect prints a select with each of its cases on its own line, indented one level deeper.
+func (pr *printer) printSelect(expr *ast.SelectExpressionNode) {
+	end := pr.end
+	pr.end = expr.EndToken
+	pr.out.WriteString("select {")
+	pr.depth++
+	pr.atBlockStart = true
+
+	for _, selectCase := range expr.Cases {
+		line, _ := selectCase.Position()
+		pr.printCommentsBefore(line)
+		pr.beginLine(line)
+		pr.out.WriteString("case ")
+		if selectCase.Name != nil {
+			pr.out.WriteString(selectCase.Name.Name + " = ")
+		}
+		pr.keepDepth(func() {
+			pr.printExpression(selectCase.Operation)
+			pr.out.WriteString(" ")
+			pr.printBlock(selectCase.Body)
+		})
+	}
+	if expr.Default != nil {
+		pr.printCommentsBefore(expr.Default.Token.Line)
+		pr.beginLine(expr.Default.Token.Line)
+		pr.out.WriteString("default ")
+		pr.printBlock(expr.Default)
+	}
+
+	pr.printCommentsBefore(expr.EndToken.Line)
+	pr.depth--
+	pr.newLine()
+	pr.out.WriteString("}")
+	pr.lastLine = expr.EndToken.Line
+	pr.end = end
+}
+
+// printMatch prints a match with each of its arms on its own line, indented one level deeper. The arms that end with
+// an expression are followed by a comma, the ones that end with a block are not.
+func (pr *printer) printMatch(expr *ast.MatchExpressionNode) {
+	pr.out.WriteString("match (")
+	pr.printExpression(expr.Value)
+	pr.out.WriteString(") {")
+	end := pr.end
+	pr.end = expr.EndToken
+	pr.depth++
+	pr.atBlockStart = true
+
+	for _, arm := range expr.Arms {
+		line, _ := arm.Position()
+		pr.printCommentsBefore(line)
+		pr.beginLine(line)
+		pr.keepDepth(func() {
+			pr.printExpression(arm.Pattern)
+			pr.out.WriteString(" => ")
+			_, isHash := arm.Value.(*ast.HashLiteralNode) // in parens, so that it is not parsed as the body of the arm
+			if arm.Body != nil {
+				pr.printBlock(arm.Body)
+			} else {
+				pr.printOperand(arm.Value, isHash)
+				pr.out.WriteString(",")
+			}
+		})
+		pr.lastLine = lastLine(arm)
+		pr.printTrailingComment()
+	}
+
+	pr.printCommentsBefore(expr.EndToken.Line)
+	pr.depth--
+	pr.newLine()
+	pr.out.WriteString("}")
+	pr.lastLine = expr.EndToken.Line
+	pr.end = end
+}
+
+func (pr *printer) printOperand(operand ast.ExpressionNode, parenthesize bool) {
+	if parenthesize {
+		pr.out.WriteString("(")
+		pr.printExpression(operand)
+		pr.out.WriteString(")")
+		return
+	}
+	pr.printExpression(operand)
+}
+
+func (pr *printer) printExpressionList(exprs []ast.ExpressionNode) {
+	for idx, expr := range exprs {
+		if idx > 0 {
+			pr.out.WriteString(",")
+			pr.printSeparator(firstLine(expr), " ")
+		}
+		pr.printExpression(expr)
+	}
+}
+
+// Precedences of the expressions, in the same order as the parser's operator precedences.
+const (
+	_ int = iota
+	lowest
+	equals      // ==, !=
+	lessGreater // <, >
+	sum         // +, -
+	product     // *, /
+	prefix      // -X, !X
+	postfix     // f(X), X?, lib.name, a[X]
+	operand     // literals, identifiers, and expressions that start with a keyword
+)
+
+var infixPrecedences = map[string]int{
+	"==": equals,
+	"!=": equals,
+	"<":  lessGreater,
+	">":  lessGreater,
+	"+":  sum,
+	"-":  sum,
+	"*":  product,
+	"/":  product,
+}
+
+// precedence returns how tightly an expression binds its operands, an operand with a lower precedence than the
+// expression it is in needs parentheses around it.
+func precedence(expr ast.ExpressionNode) int {
+	switch expr := expr.(type) {
+	case *ast.InfixExpressionNode:
+		if prec, ok := infixPrecedences[expr.Operator]; ok {
+			return prec
+		}
+		return lowest
+	case *ast.PrefixExpressionNode, *ast.SpawnExpressionNode:
+		return prefix
+	case *ast.CallExpressionNode, *ast.IndexExpressionNode, *ast.MemberExpressionNode, *ast.PropagateExpressionNode:
+		return postfix
+	default:
+		return operand
+	}
+}
+
+// startOfStatement returns the text a statement starts with when it is printed, only as far as it is needed to
+// tell whether it starts with a "(", "[" or "-".
+func startOfStatement(stmt ast.StatementNode) string {
+	var expr ast.ExpressionNode
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatementNode:
+		expr = stmt.Expression
+	case *ast.AssignStatementNode:
+		expr = stmt.Target
+	default:
+		return stmt.TokenLiteral()
+	}
+
+	for {
+		var left ast.ExpressionNode
+		switch e := expr.(type) {
+		case *ast.PrefixExpressionNode:
+			return e.Operator
+		case *ast.ArrayLiteralNode:
+			return "["
+		case *ast.HashLiteralNode:
+			return "{"
+		case *ast.StringLiteralNode:
+			return `"`
+		case *ast.InfixExpressionNode:
+			left = e.Left
+		case *ast.CallExpressionNode:
+			left = e.Function
+		case *ast.IndexExpressionNode:
+			left = e.Left
+		case *ast.MemberExpressionNode:
+			left = e.Object
+		case *ast.PropagateExpressionNode:
+			left = e.Value
+		default:
+			return expr.TokenLiteral()
+		}
+
+		if precedence(expr) > precedence(left) {
+			return "("
+		}
+		expr = left
+	}
+}
+
+// firstLine returns the line of the source code an expression starts on, which is the line of its left-most operand.
+func firstLine(expr ast.ExpressionNode) int {
+	for {
+		switch e := expr.(type) {
+		case *ast.InfixExpressionNode:
+			expr = e.Left
+		case *ast.CallExpressionNode:
+			expr = e.Function
+		case *ast.IndexExpressionNode:
+			expr = e.Left
+		case *ast.MemberExpressionNode:
+			expr = e.Object
+		case *ast.PropagateExpressionNode:
+			expr = e.Value
+		default:
+			line, _ := expr.Position()
+			return line
+		}
+	}
+}
+
+// lastLine returns the last line of the source code a node is on, as far as the positions of its nodes tell.
+func lastLine(root ast.Node) int {
+	last, _ := root.Position()
+	ast.Inspect(root, func(node ast.Node) bool {
+		line, _ := node.Position()
+		switch node := node.(type) {
+		case *ast.BlockStatementNode:
+			line = node.EndToken.Line
+		case *ast.SelectExpressionNode:
+			line = node.EndToken.Line
+		case *ast.MatchExpressionNode:
+			line = node.EndToken.Line
+		case *ast.ArrayLiteralNode:
+			line = node.EndToken.Line
+		case *ast.CallExpressionNode:
+			line = node.EndToken.Line
+		case *ast.IndexExpressionNode:
+			line = node.EndToken.Line
+		case *ast.StructLiteralNode:
+			line = node.EndToken.Line
+		case *ast.HashLiteralNode:
+			line = node.EndToken.Line
+		case *ast.HashPatternNode:
+			line = node.EndToken.Line
+		case *ast.StructStatementNode:
+			line = node.EndToken.Line
+		}
+		if line > last {
+			last = line
+		}
+		return true
+	})
+	return last
+}
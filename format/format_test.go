@@ -0,0 +1,198 @@
+package format
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/parser"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=5;", "let x = 5\n"},
+		{"let   add = func(a,b){a+b};add(1 , 2);", "let add = func(a, b) {\n  a + b\n}\nadd(1, 2)\n"},
+		{"((1 + 2)) * (3 * 4) - (5 - 6)", "(1 + 2) * (3 * 4) - (5 - 6)\n"},
+		{"-(a + b); !(-x); a - (b + c)", "-(a + b)\n!-x\na - (b + c)\n"},
+		{"(-f)(x); (a + b).len(); (a < b)?; -a.b", "(-f)(x);\n(a + b).len();\n(a < b)?;\n-a.b\n"},
+		{"x;\n(f)(1);\n[1, 2][0];\n-1", "x\nf(1);\n[1, 2][0];\n-1\n"},
+		{"if (x) { 1 } else { 2 }", "if (x) {\n  1\n} else {\n  2\n}\n"},
+		{"if (x) {}", "if (x) {}\n"},
+		{"try { f() } catch (e) { e.message } finally { 1 }", "try {\n  f()\n} catch (e) {\n  e.message\n} finally {\n  1\n}\n"},
+		{"let r = spawn  f(1,2)", "let r = spawn f(1, 2)\n"},
+		{"select { case v = receive(ch) { v } case send(out,1) {} default { 0 } }", "select {\n  case v = receive(ch) {\n    v\n  }\n  case send(out, 1) {}\n  default {\n    0\n  }\n}\n"},
+		{`import "lib/math.yz" as m;export let x = m.pi;throw "boom"`, "import \"lib/math.yz\" as m\nexport let x = m.pi\nthrow \"boom\"\n"},
+		{"const  x=1;export const y = x", "const x = 1\nexport const y = x\n"},
+		{"struct  Point{x,y};struct Unit{ }\nlet p=Point{x:1,y :[2]};p.x=p.x+1", "struct Point { x, y }\nstruct Unit {}\nlet p = Point{x: 1, y: [2]}\np.x = p.x + 1\n"},
+		{"let p = q;\n(-a).x = 1", "let p = q;\n(-a).x = 1\n"},
+		{"match(v){-1=>0,[a,_]=>a+1,{\"k\" :P{x:x}}=>{x} _=>{}}", "match (v) {\n  -1 => 0,\n  [a, _] => a + 1,\n  {\"k\": P{x: x}} => {\n    x\n  }\n  _ => {}\n}\n"},
+		{"let h={ \"a\":1,2 :[true]}; h[\"a\"];\n{}.len()", "let h = {\"a\": 1, 2: [true]}\nh[\"a\"];\n{}.len()\n"},
+		{"match(h){{\"a\":a}=>({\"b\":a}),_=>({})}", "match (h) {\n  {\"a\": a} => ({\"b\": a}),\n  _ => ({}),\n}\n"},
+		{"let f = func() { return [1,2,  3] }", "let f = func() {\n  return [1, 2, 3]\n}\n"},
+		{"let a = 1\n\n\n\nlet b = 2\nlet c = 3", "let a = 1\n\nlet b = 2\nlet c = 3\n"},
+		{"#!/usr/bin/env yeezy\nprint(\"hi\")", "#!/usr/bin/env yeezy\nprint(\"hi\")\n"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source(tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) returned an error: %s", tt.input, err)
+			continue
+		}
+
+		if formatted != tt.expected {
+			t.Errorf("Source(%q) is wrong.\nexpected=%q\ngot=     %q", tt.input, tt.expected, formatted)
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	input := `// greeting functions
+let makeGreeter = func(greet) { // returns a function
+    // the inner function
+  func(name) {
+    greet + "! " + name
+  } // end of inner
+
+  // nothing after this
+}
+
+
+// unused
+let empty = func() {
+  // nothing here
+}
+hello("joe") // call it
+// the end`
+
+	expected := `// greeting functions
+let makeGreeter = func(greet) {
+  // returns a function
+  // the inner function
+  func(name) {
+    greet + "! " + name
+  } // end of inner
+
+  // nothing after this
+}
+
+// unused
+let empty = func() {
+  // nothing here
+}
+hello("joe") // call it
+// the end
+`
+
+	formatted, err := Source(input)
+	if err != nil {
+		t.Fatalf("Source returned an error: %s", err)
+	}
+
+	if formatted != expected {
+		t.Errorf("Source is wrong.\nexpected=%q\ngot=     %q", expected, formatted)
+	}
+}
+
+// TestSourceCommentsInExpressions checks that the comments in the middle of a statement stay where they are, and
+// that the comments after a block stay after it.
+func TestSourceCommentsInExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1, // one\n 2 // two\n]", "let a = [1, // one\n  2 // two\n]\n"},
+		{"let a = [\n  // first\n  1,\n  2]", "let a = [\n  // first\n  1, 2]\n"},
+		{"if (x) { a } // trailing\nb", "if (x) {\n  a\n} // trailing\nb\n"},
+		{"let f = func() { a } // after", "let f = func() {\n  a\n} // after\n"},
+		{"f(1, // one\n  g(2 // two\n  ))", "f(1, // one\n  g(2 // two\n))\n"},
+		{"let q = 1 + // one\n  2 *\n  // three\n  3", "let q = 1 + // one\n  2 *\n  // three\n  3\n"},
+		{"let p = Point{x: 1, // one\n  y: 2}", "let p = Point{x: 1, // one\n  y: 2}\n"},
+		{"let h = {\"a\": 1, // one\n  \"b\": 2}", "let h = {\"a\": 1, // one\n  \"b\": 2}\n"},
+		{"struct Point {\n  x, // across\n  y\n} // end", "struct Point { x, // across\n  y } // end\n"},
+		{"struct Unit {\n  // nothing\n}", "struct Unit {\n  // nothing\n}\n"},
+		{"let f = func() {\n  g([1, // one\n    2])\n  h()\n}", "let f = func() {\n  g([1, // one\n    2])\n  h()\n}\n"},
+		{"match (v) {\n  [a, // a\n   b] => a,\n  _ => 0 } // after", "match (v) {\n  [a, // a\n    b] => a,\n  _ => 0,\n} // after\n"},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source(tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) returned an error: %s", tt.input, err)
+			continue
+		}
+
+		if formatted != tt.expected {
+			t.Errorf("Source(%q) is wrong.\nexpected=%q\ngot=     %q", tt.input, tt.expected, formatted)
+		}
+
+		reformatted, err := Source(formatted)
+		if err != nil || reformatted != formatted {
+			t.Errorf("formatting is not idempotent.\nformatted=  %q\nreformatted=%q", formatted, reformatted)
+		}
+	}
+}
+
+func TestSourceParseErrors(t *testing.T) {
+	_, err := Source("let = 5")
+	if err == nil {
+		t.Fatalf("Source did not return an error for a program with parse errors")
+	}
+
+	if !strings.Contains(err.Error(), "parse errors:\n\texpected next token to be") {
+		t.Errorf("wrong error. got=%q", err)
+	}
+}
+
+// TestSourceKeepsProgram checks that formatting does not change the program, and that formatted source code stays
+// the same when it is formatted again.
+func TestSourceKeepsProgram(t *testing.T) {
+	example, err := ioutil.ReadFile("../example.yz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []string{
+		string(example),
+		"let x = 1 + 2 * 3 - -4 / (5 - 6) == !(7 < 8); x",
+		"let a = [1, [2, 3]][1][0]; a.len(); f(g(h)(i))?.j",
+		"a * (b + c) - (d - e) + (f == (g != h))",
+		"let f = func(x) { if (x > 0) { return try { g(x)? } catch { 0 } } -x }; f(1)",
+		"let r = select { case v = receive(spawn f(1)) { v } // got it\n case send(ch, -1) { 0 }\n // otherwise\n default { 1 } }; r",
+		"struct Point {\n  x, // across\n  y\n} // end\nlet p = Point{x: -1, y: Point(2, 3)};\n(p.y).x = [p.x][0]",
+		"let a = [1, // one\n  f(2, // two\n  -3)[0 // index\n  ]] // end\nif (a) { a } // after\nlet b = -a + // plus\n  Point{x: 1, // x\n y: 2}.x",
+		"let h = {\"a\": [1], 2: {}}\n{\"b\": h}[\"b\"]; match (h) { {\"a\": a} => ({\"b\": a}), _ => {} }",
+		"let r = match (f(1)) { // the result\n  -1 => 0, // none\n\n  // a pair\n  [a, Point{x: b}] => { a + b }\n  _ => match (2) { x => x }\n}; r",
+	}
+
+	for _, input := range inputs {
+		formatted, err := Source(input)
+		if err != nil {
+			t.Errorf("Source(%q) returned an error: %s", input, err)
+			continue
+		}
+
+		if parse(t, formatted) != parse(t, input) {
+			t.Errorf("formatting changed the program.\ninput=    %q\nformatted=%q", input, formatted)
+		}
+
+		reformatted, err := Source(formatted)
+		if err != nil || reformatted != formatted {
+			t.Errorf("formatting is not idempotent.\nformatted=  %q\nreformatted=%q", formatted, reformatted)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		t.Fatalf("parse errors in %q: %v", input, p.Errors)
+	}
+	return program.String()
+}
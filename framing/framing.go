@@ -0,0 +1,63 @@
+// Package framing reads and writes the messages of the language server and of the debug adapter, which are both JSON
+// bodies after a header with their Content-Length.
+package framing
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxLength is the largest Content-Length a message can have, so that a wrong header does not allocate any size.
+const MaxLength = 64 << 20
+
+// Read reads one message, which is a JSON body after a header with its Content-Length.
+func Read(in *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		colon := strings.IndexByte(line, ':')
+		if colon >= 0 && strings.EqualFold(strings.TrimSpace(line[:colon]), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(line[colon+1:]))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length header %q", line)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("message without a Content-Length header")
+	}
+	if length > MaxLength {
+		return nil, fmt.Errorf("message of %d bytes is larger than the limit of %d bytes", length, MaxLength)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Write writes the JSON of msg as a message.
+func Write(out io.Writer, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
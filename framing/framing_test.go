@@ -0,0 +1,47 @@
+package framing
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadAndWrite(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Content-Length: 8\r\n\r\n{\"id\":1}" {
+		t.Fatalf("wrong message written. got=%q", out.String())
+	}
+
+	in := bufio.NewReader(strings.NewReader("Content-Type: application/json\r\ncontent-length: 2\r\n\r\n{}" + out.String()))
+	for _, expected := range []string{"{}", `{"id":1}`} {
+		body, err := Read(in)
+		if err != nil || string(body) != expected {
+			t.Errorf("wrong message read. expected=%q, got=%q (err=%v)", expected, body, err)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"\r\n{}", "message without a Content-Length header"},
+		{"Content-Length: two\r\n\r\n{}", `invalid Content-Length header "Content-Length: two"`},
+		{"Content-Length: -1\r\n\r\n{}", `invalid Content-Length header "Content-Length: -1"`},
+		{fmt.Sprintf("Content-Length: %d\r\n\r\n{}", MaxLength+1), fmt.Sprintf("message of %d bytes is larger than the limit of %d bytes", MaxLength+1, MaxLength)},
+		{"Content-Length: 5\r\n\r\n{}", "unexpected EOF"},
+	}
+
+	for _, tt := range tests {
+		_, err := Read(bufio.NewReader(strings.NewReader(tt.input)))
+		if err == nil || err.Error() != tt.expectedError {
+			t.Errorf("%q: expected the error %q, got=%v", tt.input, tt.expectedError, err)
+		}
+	}
+}
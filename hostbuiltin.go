@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/object"
+)
+
+// hostBuiltins returns the built-in functions that give a program access to the process running it.
+// They are not part of the default built-ins of the evaluator, so that an embedded interpreter is sandboxed by default.
+func hostBuiltins(scriptArgs []string, in io.Reader) map[string]object.BuiltInFunction {
+	reader := bufio.NewReader(in)
+	var readerMu sync.Mutex // spawned functions can read stdin at the same time
+
+	return map[string]object.BuiltInFunction{
+		// args returns the command-line arguments given to the program after its file name.
+		"args": func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return hostError("Wrong number of arguments. want=%d, got=%d", 0, len(args))
+			}
+
+			elements := []object.Object{}
+			for _, arg := range scriptArgs {
+				elements = append(elements, &object.String{Value: arg})
+			}
+			return &object.Array{Elements: elements}
+		},
+		// getenv returns the value of an environment variable, or null when it is not set.
+		"getenv": func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return hostError("Wrong number of arguments. want=%d, got=%d", 1, len(args))
+			}
+
+			name, ok := args[0].(*object.String)
+			if !ok {
+				return hostError("getenv doesn't support the given argument. got=%s", args[0].Type())
+			}
+
+			if value, ok := os.LookupEnv(name.Value); ok {
+				return &object.String{Value: value}
+			}
+			return evaluator.NULL
+		},
+		// readLine returns the next line of stdin without the newline, or null at the end of stdin.
+		"readLine": func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return hostError("Wrong number of arguments. want=%d, got=%d", 0, len(args))
+			}
+
+			readerMu.Lock()
+			line, err := reader.ReadString('\n')
+			readerMu.Unlock()
+			if err != nil && line == "" {
+				return evaluator.NULL
+			}
+			return &object.String{Value: strings.TrimRight(line, "\r\n")}
+		},
+		// readAll returns what is left of stdin.
+		"readAll": func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return hostError("Wrong number of arguments. want=%d, got=%d", 0, len(args))
+			}
+
+			readerMu.Lock()
+			content, err := ioutil.ReadAll(reader)
+			readerMu.Unlock()
+			if err != nil {
+				return hostError("%s", err)
+			}
+			return &object.String{Value: string(content)}
+		},
+	}
+}
+
+func hostError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
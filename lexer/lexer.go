@@ -1,6 +1,10 @@
 package lexer
 
-import "github.com/shksa/yeezy/token"
+import (
+	"strings"
+
+	"github.com/shksa/yeezy/token"
+)
 
 // Lexer is the object which generates tokens from source code.
 type Lexer struct {
@@ -8,6 +12,9 @@ type Lexer struct {
 	position     int  // points to the current character lexer has read.
 	nextPosition int  // points to next char
 	ch           byte // current char under examination
+	line         int  // line of the current char, starting at 1
+	lineStart    int  // position of the first char of the current line
+	comments     []token.Token
 }
 
 /* NOTES
@@ -19,13 +26,20 @@ to "peek" further into the input and look after the current character to see wha
 
 // New returns a pointer to a newly created Lexer object.
 func New(input string) *Lexer {
-	lexer := &Lexer{input: input}
+	lexer := &Lexer{input: input, line: 1}
 	lexer.readNextChar() // To initialize lexer.ch, lexer.postion, lexer.nextPosition
+	if lexer.ch == '#' && lexer.peekNextChar() == '!' {
+		lexer.skipLine() // A "#!/usr/bin/env yeezy" line at the start makes a program runnable as an executable script.
+	}
 	return lexer
 }
 
 // readNextChar reads the next char in the input string and stores it in the lexer's current char (ch) field.
 func (l *Lexer) readNextChar() {
+	if l.ch == '\n' { // The char after a newline is the first char of the next line.
+		l.line++
+		l.lineStart = l.nextPosition
+	}
 	if l.nextPosition >= len(l.input) {
 		l.ch = 0 // 0 is the ASCII code for the "NUL" character and signifies either "we haven't read anything yet" or "end of file" for us.
 	} else {
@@ -52,13 +66,17 @@ It looks at the current char and returns a token depending on what char it is.
 It advances the lexer's position before returning the token
 */
 func (l *Lexer) NextToken() token.Token {
-	l.skipWhiteSpace()
+	l.skipWhiteSpaceAndComments()
 	var tok token.Token
+	line, column := l.line, l.position-l.lineStart+1
 	switch l.ch {
 	case '=':
 		if l.peekNextChar() == '=' {
 			l.readNextChar()
 			tok = token.EQ
+		} else if l.peekNextChar() == '>' {
+			l.readNextChar()
+			tok = token.ARROW
 		} else {
 			tok = token.ASSIGN
 		}
@@ -85,27 +103,44 @@ func (l *Lexer) NextToken() token.Token {
 		tok = token.LBRACE
 	case '}':
 		tok = token.RBRACE
+	case '[':
+		tok = token.LBRACKET
+	case ']':
+		tok = token.RBRACKET
 	case ',':
 		tok = token.COMMA
 	case ';':
 		tok = token.SEMICOLON
+	case '.':
+		tok = token.DOT
+	case '?':
+		tok = token.QUESTION
+	case ':':
+		tok = token.COLON
 	case '<':
 		tok = token.LT
 	case '>':
 		tok = token.GT
 	case '"':
-		tok = token.STRING
-		tok.Literal = l.readString()
+		literal, terminated := l.readString()
+		if terminated {
+			tok = token.STRING
+		} else {
+			tok = token.UNTERMINATED
+		}
+		tok.Literal = literal
 	case 0:
 		tok = token.EOF
 	default:
 		if isLetter(l.ch) {
 			letterStringLiteral := l.readLetterString()
 			tok = token.GetTokenForLetterStringLiteral(letterStringLiteral)
+			tok.Line, tok.Column = line, column
 			return tok
 		} else if isDigit(l.ch) {
 			tok = token.INT
 			tok.Literal = l.readNumber()
+			tok.Line, tok.Column = line, column
 			return tok
 		} else {
 			tok = token.ILLEGAL
@@ -115,6 +150,7 @@ func (l *Lexer) NextToken() token.Token {
 
 	l.readNextChar()
 
+	tok.Line, tok.Column = line, column
 	return tok
 }
 
@@ -135,12 +171,39 @@ func (l *Lexer) readNumber() string {
 	return l.input[position:l.position]
 }
 
+// skipLine moves the lexer to the newline at the end of the current line.
+func (l *Lexer) skipLine() {
+	for l.ch != '\n' && l.ch != 0 {
+		l.readNextChar()
+	}
+}
+
 func (l *Lexer) skipWhiteSpace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		l.readNextChar()
 	}
 }
 
+// skipWhiteSpaceAndComments skips white space and "//" comments, which run till the end of the line.
+// Comments are not tokens of the program, but they are kept so that tools like the formatter can put them back.
+func (l *Lexer) skipWhiteSpaceAndComments() {
+	l.skipWhiteSpace()
+	for l.ch == '/' && l.peekNextChar() == '/' {
+		comment := token.COMMENT
+		comment.Line, comment.Column = l.line, l.position-l.lineStart+1
+		position := l.position
+		l.skipLine()
+		comment.Literal = strings.TrimRight(l.input[position:l.position], "\r")
+		l.comments = append(l.comments, comment)
+		l.skipWhiteSpace()
+	}
+}
+
+// Comments returns the comments the lexer has skipped so far, in the order they appear in the input.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
 // peekNextChar returns the next char in the input without moving the position and updating the current char ch field of lexer.
 func (l *Lexer) peekNextChar() byte {
 	if l.nextPosition >= len(l.input) {
@@ -158,13 +221,15 @@ func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func (l *Lexer) readString() string {
+// readString returns the chars between the quotes of a string, and whether it has its closing quote. A string without
+// it runs till the end of the input.
+func (l *Lexer) readString() (string, bool) {
 	startPos := l.position + 1
 	for {
 		l.readNextChar()
-		if l.ch == '"' {
+		if l.ch == '"' || l.ch == 0 {
 			break
 		}
 	}
-	return l.input[startPos:l.position]
+	return l.input[startPos:l.position], l.ch == '"'
 }
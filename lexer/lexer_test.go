@@ -157,3 +157,172 @@ func TestNextTokenSingleLineInput(t *testing.T) {
 		}
 	}
 }
+
+func TestNextTokenBracketsAndDots(t *testing.T) {
+	input := `arr[0].push("x")`
+	tests := []token.Token{
+		{Type: "IDENTIFIER", Literal: "arr"},
+		token.LBRACKET,
+		{Type: "INT", Literal: "0"},
+		token.RBRACKET,
+		token.DOT,
+		{Type: "IDENTIFIER", Literal: "push"},
+		token.LPAREN,
+		{Type: "STRING", Literal: "x"},
+		token.RPAREN,
+		token.EOF,
+	}
+
+	lexer := New(input)
+
+	for i, expected := range tests {
+		tok := lexer.NextToken()
+		if tok.Type != expected.Type || tok.Literal != expected.Literal {
+			t.Fatalf("tests[%d] - wrong token. expected %q %q, got %q %q", i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenMatch(t *testing.T) {
+	input := `match (v) { {"k": x} => x == 1, _=>0 }`
+	tests := []token.Token{
+		token.MATCH,
+		token.LPAREN,
+		{Type: "IDENTIFIER", Literal: "v"},
+		token.RPAREN,
+		token.LBRACE,
+		token.LBRACE,
+		{Type: "STRING", Literal: "k"},
+		token.COLON,
+		{Type: "IDENTIFIER", Literal: "x"},
+		token.RBRACE,
+		token.ARROW,
+		{Type: "IDENTIFIER", Literal: "x"},
+		token.EQ,
+		{Type: "INT", Literal: "1"},
+		token.COMMA,
+		{Type: "IDENTIFIER", Literal: "_"},
+		token.ARROW,
+		{Type: "INT", Literal: "0"},
+		token.RBRACE,
+		token.EOF,
+	}
+
+	lexer := New(input)
+
+	for i, expected := range tests {
+		tok := lexer.NextToken()
+		if tok.Type != expected.Type || tok.Literal != expected.Literal {
+			t.Fatalf("tests[%d] - wrong token. expected %q %q, got %q %q", i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  x + \"ab\""
+	tests := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"x", 2, 3},
+		{"+", 2, 5},
+		{"ab", 2, 7},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		tok := lexer.NextToken()
+		if tok.Literal != tt.literal || tok.Line != tt.line || tok.Column != tt.column {
+			t.Fatalf("tests[%d] - wrong token. expected %q at %d:%d, got %q at %d:%d", i, tt.literal, tt.line, tt.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	lexer := New("let s = \"abc\nlet t = 1;\n")
+	for i := 0; i < 3; i++ {
+		lexer.NextToken()
+	}
+
+	tok := lexer.NextToken()
+	if tok.Type != token.UNTERMINATED.Type || tok.Literal != "abc\nlet t = 1;\n" || tok.Line != 1 || tok.Column != 9 {
+		t.Fatalf("wrong token. expected UNTERMINATED at 1:9, got %+v", tok)
+	}
+	if tok := lexer.NextToken(); tok.Type != token.EOF.Type {
+		t.Fatalf("expected EOF after the unterminated string, got %+v", tok)
+	}
+}
+
+func TestShebangLine(t *testing.T) {
+	lexer := New("#!/usr/bin/env yeezy\nlet x")
+
+	tok := lexer.NextToken()
+	if tok.Type != token.LET.Type || tok.Line != 2 {
+		t.Fatalf("shebang line was not skipped. got %q at line %d", tok.Literal, tok.Line)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "// leading\nlet x = 5 // trailing\n// last"
+	lexer := New(input)
+
+	expectedTokens := []string{"let", "x", "=", "5", ""}
+	for i, expected := range expectedTokens {
+		tok := lexer.NextToken()
+		if tok.Literal != expected {
+			t.Fatalf("tests[%d] - token.Literal is wrong. expected %q, got %q", i, expected, tok.Literal)
+		}
+	}
+
+	expectedComments := []token.Token{
+		{Type: "COMMENT", Literal: "// leading", Line: 1, Column: 1},
+		{Type: "COMMENT", Literal: "// trailing", Line: 2, Column: 11},
+		{Type: "COMMENT", Literal: "// last", Line: 3, Column: 1},
+	}
+	comments := lexer.Comments()
+	if len(comments) != len(expectedComments) {
+		t.Fatalf("wrong number of comments. expected %d, got %d (%v)", len(expectedComments), len(comments), comments)
+	}
+	for i, expected := range expectedComments {
+		if comments[i] != expected {
+			t.Errorf("comments[%d] is wrong. expected %+v, got %+v", i, expected, comments[i])
+		}
+	}
+}
+
+// FuzzNextToken checks that the lexer never panics, and that it reaches the end of any input, every token but the
+// last one consumes at least a character of it.
+func FuzzNextToken(f *testing.F) {
+	for _, seed := range []string{
+		"let add = func(x, y) { x + y; }; add(1, 2)",
+		`"unterminated`,
+		"a.b[0] == !c // comment",
+		"import \"lib.yz\" as lib\nexport let x = try { throw 1 } catch (e) { e } finally { 2 }",
+		"@#$ 0x12 \x00 \xff",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		l := New(input)
+		for count := 0; ; count++ {
+			if count > len(input)+1 {
+				t.Fatalf("the lexer did not reach the end of %q after %d tokens", input, count)
+			}
+			tok := l.NextToken()
+			if tok.Type == token.EOF.Type {
+				break
+			}
+			if tok.Line < 1 || tok.Column < 1 {
+				t.Fatalf("token %+v of %q has no position", tok, input)
+			}
+		}
+	})
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shksa/yeezy/token"
+)
+
+// HISTORYFILE is the name of the file, in the user's home directory, that keeps the repl's history across sessions.
+const HISTORYFILE = ".yeezy_history"
+
+// historyLimit is the number of lines of the history file that are loaded when the repl starts.
+const historyLimit = 1000
+
+// errPromptAborted is returned by a lineReader when the user cancels the line being typed with Ctrl-C.
+var errPromptAborted = errors.New("prompt aborted")
+
+// lineReader is an interface for reading the repl's input one line at a time.
+type lineReader interface {
+	Prompt(prompt string) (string, error)
+	Close() error
+}
+
+// newLineReader returns an editingLineReader when the input is typed in a terminal, and a scannerLineReader otherwise.
+func newLineReader(session *session, in *os.File, out io.Writer, historyPath string) lineReader {
+	if !isTerminal(in.Fd()) {
+		return &scannerLineReader{scanner: bufio.NewScanner(in), out: out}
+	}
+	return newEditingLineReader(session, in, out, historyPath)
+}
+
+// scannerLineReader reads lines with a bufio.Scanner, it is used when the input is not typed in a terminal.
+type scannerLineReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+// Prompt writes the prompt and returns the next line of the input.
+func (r *scannerLineReader) Prompt(prompt string) (string, error) {
+	io.WriteString(r.out, prompt)
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// Close does nothing, the input belongs to the caller.
+func (r *scannerLineReader) Close() error {
+	return nil
+}
+
+// editingLineReader reads lines from the terminal with arrow-key editing, history and tab completion.
+type editingLineReader struct {
+	in       *bufio.Reader
+	out      io.Writer
+	fd       uintptr // the terminal, which is in raw mode while a line is typed
+	complete func(word string, atLineStart bool) []string
+	history  []string
+	// historyFile is where the lines are saved, opened for appending so that the sessions running at the same time
+	// add their lines to it instead of overwriting each other's. It is nil when the history is not saved.
+	historyFile *os.File
+}
+
+func newEditingLineReader(session *session, in *os.File, out io.Writer, historyPath string) *editingLineReader {
+	r := &editingLineReader{in: bufio.NewReader(in), out: out, fd: in.Fd(), complete: session.complete}
+	if historyPath == "" {
+		return r
+	}
+
+	if content, err := ioutil.ReadFile(historyPath); err == nil {
+		r.history = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		if len(r.history) > historyLimit {
+			r.history = r.history[len(r.history)-historyLimit:]
+		}
+	}
+	if historyFile, err := os.OpenFile(historyPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600); err == nil {
+		r.historyFile = historyFile
+	}
+	return r
+}
+
+// Prompt reads a line from the terminal and adds it to the history, unless it is the same as the last line of it.
+func (r *editingLineReader) Prompt(prompt string) (string, error) {
+	if restore, ok := makeRaw(r.fd); ok {
+		defer restore()
+	}
+
+	line, err := r.edit(prompt)
+	if err == nil && strings.TrimSpace(line) != "" && (len(r.history) == 0 || r.history[len(r.history)-1] != line) {
+		r.history = append(r.history, line)
+		if r.historyFile != nil {
+			io.WriteString(r.historyFile, line+"\n") // a single write, so that it is not mixed with the ones of other sessions
+		}
+	}
+	return line, err
+}
+
+// Close closes the history file, every line was already saved to it.
+func (r *editingLineReader) Close() error {
+	if r.historyFile == nil {
+		return nil
+	}
+	return r.historyFile.Close()
+}
+
+// The keys the line editor handles.
+const (
+	keyCtrlA     = 1
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyBackspace = 8
+	keyTab       = 9
+	keyEnter     = 13
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// editState is a type for representing the line being edited.
+type editState struct {
+	line       []rune
+	pos        int // the index of the cursor in line
+	history    []string
+	historyIdx int    // the line of the history that is shown, len(history) for the line being typed
+	typed      string // the line being typed, kept while the history is shown
+}
+
+func (st *editState) insert(text string) {
+	inserted := []rune(text)
+	st.line = append(st.line[:st.pos], append(inserted, st.line[st.pos:]...)...)
+	st.pos += len(inserted)
+}
+
+// delete deletes the char at idx, if there is one.
+func (st *editState) delete(idx int) {
+	if 0 <= idx && idx < len(st.line) {
+		st.line = append(st.line[:idx], st.line[idx+1:]...)
+		if idx < st.pos {
+			st.pos--
+		}
+	}
+}
+
+func (st *editState) moveTo(pos int) {
+	if 0 <= pos && pos <= len(st.line) {
+		st.pos = pos
+	}
+}
+
+// showHistory replaces the line with the line of the history at idx, or with the line being typed when idx is past the
+// last one.
+func (st *editState) showHistory(idx int) {
+	if idx < 0 || idx > len(st.history) {
+		return
+	}
+	if st.historyIdx == len(st.history) {
+		st.typed = string(st.line)
+	}
+	st.historyIdx = idx
+	if idx == len(st.history) {
+		st.line = []rune(st.typed)
+	} else {
+		st.line = []rune(st.history[idx])
+	}
+	st.pos = len(st.line)
+}
+
+// edit reads the keys typed by the user till the line is entered, and redraws the line after every key. The terminal
+// is in raw mode, so the keys come one at a time and nothing is echoed.
+func (r *editingLineReader) edit(prompt string) (string, error) {
+	st := &editState{line: []rune{}, history: r.history, historyIdx: len(r.history)}
+
+	io.WriteString(r.out, prompt)
+	for {
+		key, _, err := r.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyEnter, '\n':
+			io.WriteString(r.out, "\r\n")
+			return string(st.line), nil
+		case keyCtrlC:
+			io.WriteString(r.out, "^C\r\n")
+			return "", errPromptAborted
+		case keyCtrlD:
+			if len(st.line) == 0 {
+				io.WriteString(r.out, "\r\n")
+				return "", io.EOF
+			}
+			st.delete(st.pos)
+		case keyBackspace, keyDelete:
+			st.delete(st.pos - 1)
+		case keyCtrlA:
+			st.moveTo(0)
+		case keyCtrlE:
+			st.moveTo(len(st.line))
+		case keyTab:
+			head, word := splitWordBeforeCursor(string(st.line[:st.pos]))
+			completions := r.complete(word, head == "")
+			if prefix := commonPrefix(completions); len(prefix) > len(word) {
+				st.insert(prefix[len(word):])
+			} else if len(completions) > 1 {
+				fmt.Fprintf(r.out, "\r\n%s\r\n", strings.Join(completions, "  "))
+			}
+		case keyEscape:
+			r.editEscapeSequence(st)
+		default:
+			if key >= ' ' {
+				st.insert(string(key))
+			}
+		}
+
+		fmt.Fprintf(r.out, "\r%s%s\x1b[K", prompt, string(st.line))
+		if len(st.line) > st.pos {
+			fmt.Fprintf(r.out, "\x1b[%dD", len(st.line)-st.pos)
+		}
+	}
+}
+
+// editEscapeSequence handles the keys that are sent as an escape sequence: the arrow keys move the cursor and go
+// through the history, and home, end and delete do what they say.
+func (r *editingLineReader) editEscapeSequence(st *editState) {
+	if next, _, err := r.in.ReadRune(); err != nil || next != '[' && next != 'O' {
+		return
+	}
+	key, _, err := r.in.ReadRune()
+	if err != nil {
+		return
+	}
+	if '0' <= key && key <= '9' { // like "\x1b[3~", the sequence ends with a ~
+		if end, _, err := r.in.ReadRune(); err != nil || end != '~' {
+			return
+		}
+	}
+
+	switch key {
+	case 'A':
+		st.showHistory(st.historyIdx - 1)
+	case 'B':
+		st.showHistory(st.historyIdx + 1)
+	case 'C':
+		st.moveTo(st.pos + 1)
+	case 'D':
+		st.moveTo(st.pos - 1)
+	case 'H', '1':
+		st.moveTo(0)
+	case 'F', '4':
+		st.moveTo(len(st.line))
+	case '3':
+		st.delete(st.pos)
+	}
+}
+
+// commonPrefix returns the longest prefix the words all start with.
+func commonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// historyFilePath returns the path of the history file, or an empty path when the home directory is unknown.
+func historyFilePath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, HISTORYFILE)
+}
+
+// splitWordBeforeCursor splits the text before the cursor into the word that is being typed and what comes before it.
+func splitWordBeforeCursor(text string) (head, word string) {
+	idx := len(text)
+	for idx > 0 && isWordChar(text[idx-1]) {
+		idx--
+	}
+	return text[:idx], text[idx:]
+}
+
+func isWordChar(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || ch == '_' || ch == ':'
+}
+
+// complete returns the keywords, built-in functions and names bound in the session that start with the word.
+// Colon commands are completed only at the start of the line.
+func (s *session) complete(word string, atLineStart bool) []string {
+	if strings.HasPrefix(word, ":") {
+		completions := []string{}
+		if atLineStart {
+			for _, cmd := range replCommands {
+				name := strings.Fields(cmd.usage)[0]
+				if strings.HasPrefix(name, word) {
+					completions = append(completions, name+" ")
+				}
+			}
+		}
+		return completions
+	}
+
+	seen := map[string]bool{}
+	completions := []string{}
+	candidates := [][]string{token.Keywords(), s.interpreter.BuiltinNames(), s.env.Names()}
+	for _, names := range candidates {
+		for _, name := range names {
+			if strings.HasPrefix(name, word) && !seen[name] {
+				seen[name] = true
+				completions = append(completions, name)
+			}
+		}
+	}
+	return completions
+}
@@ -0,0 +1,418 @@
+// Package lsp is a Language Server Protocol server for yeezy programs, the way `yeezy lsp` runs it.
+//
+// It gives editors the diagnostics of the parser and of the checker, hover information and go-to-definition for
+// names, the outline of a document, and formatting with the formatter. Documents are synced in full on every change.
+//
+// Columns are counted in bytes, so positions are exact for ASCII source code only.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/check"
+	"github.com/shksa/yeezy/format"
+	"github.com/shksa/yeezy/framing"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/parser"
+	"github.com/shksa/yeezy/token"
+)
+
+// Server is a type for representing a language server talking to one editor.
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	builtins  []string
+	documents map[string]*document
+}
+
+// document is a type for holding an open document and what the server found out about it.
+type document struct {
+	text    string
+	lines   []string
+	program *ast.Program  // nil when the document has parse errors
+	result  *check.Result // nil when the document has parse errors
+}
+
+// New returns a server reading messages from in and writing messages to out.
+// builtins are the names of the built-in functions programs can call.
+func New(in io.Reader, out io.Writer, builtins []string) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		builtins:  builtins,
+		documents: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the editor sends the exit notification or closes the connection.
+func (s *Server) Serve() error {
+	for {
+		body, err := framing.Read(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return fmt.Errorf("invalid message: %s", err)
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, respErr := s.handle(msg)
+		if msg.ID == nil {
+			continue // a notification does not get a response
+		}
+
+		if err := framing.Write(s.out, response{JSONRPC: "2.0", ID: msg.ID, Result: result, Error: respErr}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg message) (interface{}, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":           1, // the full text on every change
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"documentSymbolProvider":     true,
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "yeezy"},
+		}, nil
+
+	case "shutdown":
+		return nil, nil // there is nothing to clean up before the exit notification
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if len(params.ContentChanges) > 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+
+	case "textDocument/didClose":
+		var params documentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	case "textDocument/hover":
+		var params positionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.hover(params), nil
+
+	case "textDocument/definition":
+		var params positionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.definition(params), nil
+
+	case "textDocument/documentSymbol":
+		var params documentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.documentSymbols(params), nil
+
+	case "textDocument/formatting":
+		var params documentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.formatting(params), nil
+
+	default:
+		if msg.ID != nil {
+			return nil, &responseError{Code: codeMethodNotFound, Message: "method not supported: " + msg.Method}
+		}
+	}
+
+	return nil, nil
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	framing.Write(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// update parses and checks the new text of a document, and sends its diagnostics to the editor.
+func (s *Server) update(uri, text string) {
+	doc := &document{text: text, lines: strings.Split(text, "\n")}
+	s.documents[uri] = doc
+
+	diagnostics := []Diagnostic{}
+
+	p := parser.New(lexer.New(text))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		for idx, errMsg := range p.Errors {
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    tokenRange(p.ErrorTokens[idx]),
+				Severity: severityError,
+				Source:   "yeezy",
+				Message:  errMsg,
+			})
+		}
+	} else {
+		doc.program = program
+		doc.result = check.Program(program, s.builtins)
+		for _, d := range doc.result.Diagnostics {
+			severity := severityWarning
+			if d.Severity == check.Error {
+				severity = severityError
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    Range{Start: position(d.Line, d.Column), End: position(d.Line, d.Column+wordLength(doc, d.Line, d.Column))},
+				Severity: severity,
+				Source:   "yeezy check",
+				Message:  d.Message,
+			})
+		}
+	}
+
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+// identifierAt returns the identifier at a position of a document, and the binding it refers to.
+func (s *Server) identifierAt(params positionParams) (*document, *ast.IdentifierNode, *check.Binding) {
+	doc := s.documents[params.TextDocument.URI]
+	if doc == nil || doc.program == nil {
+		return nil, nil, nil
+	}
+
+	line, column := params.Position.Line+1, params.Position.Character+1
+	var found *ast.IdentifierNode
+	ast.Inspect(doc.program, func(node ast.Node) bool {
+		if iden, ok := node.(*ast.IdentifierNode); ok && iden.Token.Line == line &&
+			iden.Token.Column <= column && column <= iden.Token.Column+len(iden.Name) {
+			found = iden
+		}
+		return found == nil
+	})
+
+	if found == nil {
+		return doc, nil, nil
+	}
+	return doc, found, doc.result.Identifiers[found]
+}
+
+func (s *Server) hover(params positionParams) *Hover {
+	doc, iden, binding := s.identifierAt(params)
+	if binding == nil {
+		return nil
+	}
+
+	var value string
+	if binding.Kind == check.Builtin {
+		value = fmt.Sprintf("built-in function `%s`", binding.Name)
+	} else {
+		line, _ := binding.Position()
+		value = fmt.Sprintf("```yeezy\n%s\n```\n%s `%s`, defined on line %d", strings.TrimSpace(doc.line(line)), binding.Kind, binding.Name, line)
+	}
+
+	identRange := identifierRange(iden)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: &identRange}
+}
+
+func (s *Server) definition(params positionParams) *Location {
+	_, _, binding := s.identifierAt(params)
+	if binding == nil || binding.Kind == check.Builtin {
+		return nil
+	}
+
+	location := &Location{URI: params.TextDocument.URI}
+	if binding.Iden != nil {
+		location.Range = identifierRange(binding.Iden)
+	} else {
+		location.Range = nodeRange(binding.Node)
+	}
+	return location
+}
+
+// documentSymbols returns the let bindings and imports of a document, with the let bindings in function bodies as
+// the children of the function.
+func (s *Server) documentSymbols(params documentParams) []DocumentSymbol {
+	doc := s.documents[params.TextDocument.URI]
+	if doc == nil || doc.program == nil {
+		return []DocumentSymbol{}
+	}
+	return symbols(doc.program.Statements)
+}
+
+func symbols(stmts []ast.StatementNode) []DocumentSymbol {
+	result := []DocumentSymbol{}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.ExportStatementNode:
+			symbol := letSymbol(stmt.Statement)
+			symbol.Range = nodeRange(stmt)
+			symbol.Detail = "export"
+			result = append(result, symbol)
+		case *ast.LetStatementNode:
+			result = append(result, letSymbol(stmt))
+		case *ast.StructStatementNode:
+			fields := []string{}
+			for _, field := range stmt.Fields {
+				fields = append(fields, field.Name)
+			}
+			result = append(result, DocumentSymbol{Name: stmt.Name.Name, Detail: "struct { " + strings.Join(fields, ", ") + " }", Kind: symbolStruct, Range: nodeRange(stmt), SelectionRange: identifierRange(stmt.Name)})
+		case *ast.ImportStatementNode:
+			name := stmt.Path.Value
+			selection := identifierRange(&ast.IdentifierNode{Token: stmt.Path.Token, Name: stmt.Path.Value})
+			if stmt.Alias != nil {
+				name = stmt.Alias.Name
+				selection = identifierRange(stmt.Alias)
+			}
+			result = append(result, DocumentSymbol{Name: name, Detail: "import", Kind: symbolModule, Range: nodeRange(stmt), SelectionRange: selection})
+		}
+	}
+	return result
+}
+
+func letSymbol(stmt *ast.LetStatementNode) DocumentSymbol {
+	symbol := DocumentSymbol{
+		Name:           stmt.Iden.Name,
+		Kind:           symbolVariable,
+		Range:          nodeRange(stmt),
+		SelectionRange: identifierRange(stmt.Iden),
+	}
+	if stmt.IsConst() {
+		symbol.Kind = symbolConstant
+	}
+
+	if function, ok := stmt.Value.(*ast.FunctionLiteralNode); ok {
+		symbol.Kind = symbolFunction
+		params := []string{}
+		for _, param := range function.Parameters {
+			params = append(params, param.Name)
+		}
+		symbol.Detail = "func(" + strings.Join(params, ", ") + ")"
+		symbol.Children = symbols(function.Body.Statements)
+	}
+	return symbol
+}
+
+// formatting returns an edit replacing the whole document with its formatted text, or nothing if the document
+// has parse errors or is already formatted.
+func (s *Server) formatting(params documentParams) []TextEdit {
+	doc := s.documents[params.TextDocument.URI]
+	if doc == nil {
+		return nil
+	}
+
+	formatted, err := format.Source(doc.text)
+	if err != nil || formatted == doc.text {
+		return []TextEdit{}
+	}
+
+	whole := Range{End: Position{Line: len(doc.lines), Character: 0}}
+	return []TextEdit{{Range: whole, NewText: formatted}}
+}
+
+// line returns the text of a line of the document, lines start at 1.
+func (doc *document) line(line int) string {
+	if line < 1 || line > len(doc.lines) {
+		return ""
+	}
+	return doc.lines[line-1]
+}
+
+// wordLength returns the length of the identifier, number or keyword at a position of a document, or 1 if there is
+// none, so that a diagnostic underlines at least one character.
+func wordLength(doc *document, line, column int) int {
+	text := doc.line(line)
+	length := 0
+	for idx := column - 1; idx >= 0 && idx < len(text); idx++ {
+		ch := text[idx]
+		if !('a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || ch == '_') {
+			break
+		}
+		length++
+	}
+	if length == 0 {
+		return 1
+	}
+	return length
+}
+
+// position converts a line and column starting at 1 to an LSP position.
+func position(line, column int) Position {
+	if line < 1 {
+		return Position{}
+	}
+	if column < 1 {
+		column = 1
+	}
+	return Position{Line: line - 1, Character: column - 1}
+}
+
+func tokenRange(tok token.Token) Range {
+	length := len(tok.Literal)
+	if length == 0 {
+		length = 1
+	}
+	return Range{Start: position(tok.Line, tok.Column), End: position(tok.Line, tok.Column+length)}
+}
+
+func identifierRange(iden *ast.IdentifierNode) Range {
+	return Range{Start: position(iden.Token.Line, iden.Token.Column), End: position(iden.Token.Line, iden.Token.Column+len(iden.Name))}
+}
+
+// nodeRange returns the range from the start of a node to the end of the last token of it that the tree knows of.
+func nodeRange(node ast.Node) Range {
+	line, column := node.Position()
+	start := position(line, column)
+	end := position(line, column+len(node.TokenLiteral()))
+
+	ast.Inspect(node, func(child ast.Node) bool {
+		childLine, childColumn := child.Position()
+		childEnd := position(childLine, childColumn+len(child.TokenLiteral()))
+		switch child := child.(type) {
+		case *ast.BlockStatementNode:
+			childEnd = position(child.EndToken.Line, child.EndToken.Column+1)
+		case *ast.SelectExpressionNode:
+			childEnd = position(child.EndToken.Line, child.EndToken.Column+1)
+		case *ast.MatchExpressionNode:
+			childEnd = position(child.EndToken.Line, child.EndToken.Column+1)
+		case *ast.StructStatementNode:
+			childEnd = position(child.EndToken.Line, child.EndToken.Column+1)
+		}
+		if childEnd.Line > end.Line || childEnd.Line == end.Line && childEnd.Character > end.Character {
+			end = childEnd
+		}
+		return true
+	})
+
+	return Range{Start: start, End: end}
+}
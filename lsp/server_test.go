@@ -0,0 +1,212 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/shksa/yeezy/framing"
+)
+
+const testURI = "file:///tmp/main.yz"
+
+// testSession sends the messages to a server, and returns the messages the server sent back.
+func testSession(t *testing.T, messages ...string) []map[string]interface{} {
+	var in bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	var out bytes.Buffer
+	if err := New(&in, &out, []string{"len"}).Serve(); err != nil {
+		t.Fatalf("Serve returned an error: %s", err)
+	}
+
+	replies := []map[string]interface{}{}
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := framing.Read(reader)
+		if err == io.EOF {
+			return replies
+		}
+		if err != nil {
+			t.Fatalf("invalid message from the server: %s", err)
+		}
+
+		var reply map[string]interface{}
+		if err := json.Unmarshal(body, &reply); err != nil {
+			t.Fatalf("invalid JSON from the server: %s", err)
+		}
+		replies = append(replies, reply)
+	}
+}
+
+func request(id int, method, params string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":%s}`, id, method, params)
+}
+
+func didOpen(text string) string {
+	textJSON, _ := json.Marshal(text)
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":%q,"languageId":"yeezy","version":1,"text":%s}}}`, testURI, textJSON)
+}
+
+func atPosition(line, character int) string {
+	return fmt.Sprintf(`{"textDocument":{"uri":%q},"position":{"line":%d,"character":%d}}`, testURI, line, character)
+}
+
+// toJSON returns the JSON of a decoded value, to compare it with the expected JSON.
+func toJSON(t *testing.T, value interface{}) string {
+	out, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestInitializeAndShutdown(t *testing.T) {
+	replies := testSession(t,
+		request(1, "initialize", `{"capabilities":{}}`),
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		request(2, "workspace/symbol", `{}`),
+		request(3, "shutdown", `null`),
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		request(4, "shutdown", `null`),
+	)
+
+	if len(replies) != 3 {
+		t.Fatalf("wrong number of replies. expected=3, got=%d: %v", len(replies), replies)
+	}
+
+	capabilities := toJSON(t, replies[0]["result"].(map[string]interface{})["capabilities"])
+	for _, capability := range []string{"hoverProvider", "definitionProvider", "documentSymbolProvider", "documentFormattingProvider"} {
+		if !strings.Contains(capabilities, `"`+capability+`":true`) {
+			t.Errorf("capability %s is missing from %s", capability, capabilities)
+		}
+	}
+
+	if replies[1]["error"].(map[string]interface{})["code"].(float64) != codeMethodNotFound {
+		t.Errorf("wrong error for an unknown method. got=%v", replies[1])
+	}
+
+	if result, ok := replies[2]["result"]; !ok || result != nil {
+		t.Errorf("wrong reply to shutdown. got=%v", replies[2])
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	replies := testSession(t,
+		didOpen("let x = 1\nlet = 5"),
+		didOpen("let f = func(a) { a + y }\nf(1, 2)"),
+	)
+
+	if len(replies) != 2 {
+		t.Fatalf("wrong number of replies. expected=2, got=%d: %v", len(replies), replies)
+	}
+
+	parseErrors := toJSON(t, replies[0]["params"])
+	expected := `"range":{"end":{"character":5,"line":1},"start":{"character":4,"line":1}},"severity":1,"source":"yeezy"`
+	if !strings.Contains(parseErrors, expected) {
+		t.Errorf("wrong diagnostics for parse errors. expected to contain %s, got=%s", expected, parseErrors)
+	}
+
+	checkErrors := replies[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	expectedDiagnostics := []string{
+		`{"message":"identifier not found: y","range":{"end":{"character":23,"line":0},"start":{"character":22,"line":0}},"severity":1,"source":"yeezy check"}`,
+		`{"message":"f takes 1 argument, but is called with 2","range":{"end":{"character":1,"line":1},"start":{"character":0,"line":1}},"severity":2,"source":"yeezy check"}`,
+	}
+	if len(checkErrors) != len(expectedDiagnostics) {
+		t.Fatalf("wrong number of diagnostics. expected=%d, got=%v", len(expectedDiagnostics), checkErrors)
+	}
+	for i, expected := range expectedDiagnostics {
+		if got := toJSON(t, checkErrors[i]); got != expected {
+			t.Errorf("diagnostics[%d] is wrong.\nexpected=%s\ngot=     %s", i, expected, got)
+		}
+	}
+}
+
+func TestHoverAndDefinition(t *testing.T) {
+	src := "let add = func(a, b) { a + b }\nadd(len(\"x\"), 2)"
+	replies := testSession(t,
+		didOpen(src),
+		request(1, "textDocument/hover", atPosition(1, 1)),
+		request(2, "textDocument/definition", atPosition(1, 2)),
+		request(3, "textDocument/definition", atPosition(0, 23)),
+		request(4, "textDocument/hover", atPosition(1, 5)),
+		request(5, "textDocument/definition", atPosition(1, 5)),
+		request(6, "textDocument/hover", atPosition(1, 13)),
+	)
+
+	tests := []struct {
+		reply    map[string]interface{}
+		expected string
+	}{
+		{replies[1], `{"contents":{"kind":"markdown","value":"` + "```yeezy\\nlet add = func(a, b) { a + b }\\n```\\nlet `add`, defined on line 1" + `"},"range":{"end":{"character":3,"line":1},"start":{"character":0,"line":1}}}`},
+		{replies[2], `{"range":{"end":{"character":7,"line":0},"start":{"character":4,"line":0}},"uri":"file:///tmp/main.yz"}`},
+		{replies[3], `{"range":{"end":{"character":16,"line":0},"start":{"character":15,"line":0}},"uri":"file:///tmp/main.yz"}`},
+		{replies[4], `{"contents":{"kind":"markdown","value":"built-in function ` + "`len`" + `"},"range":{"end":{"character":7,"line":1},"start":{"character":4,"line":1}}}`},
+		{replies[5], `null`},
+		{replies[6], `null`},
+	}
+
+	for i, tt := range tests {
+		if got := toJSON(t, tt.reply["result"]); got != tt.expected {
+			t.Errorf("tests[%d] - wrong result.\nexpected=%s\ngot=     %s", i, tt.expected, got)
+		}
+	}
+}
+
+func TestDocumentSymbolsAndFormatting(t *testing.T) {
+	src := "import \"math.yz\" as m\nlet area = func(r) {\n  let pi = m.pi\n  pi * r * r\n}\nexport let unit=area(1)"
+	replies := testSession(t,
+		didOpen(src),
+		request(1, "textDocument/documentSymbol", fmt.Sprintf(`{"textDocument":{"uri":%q}}`, testURI)),
+		request(2, "textDocument/formatting", fmt.Sprintf(`{"textDocument":{"uri":%q},"options":{"tabSize":2}}`, testURI)),
+	)
+
+	symbols := replies[1]["result"].([]interface{})
+	expectedSymbols := []string{
+		`{"detail":"import","kind":2,"name":"m","range":{"end":{"character":21,"line":0},"start":{"character":0,"line":0}},"selectionRange":{"end":{"character":21,"line":0},"start":{"character":20,"line":0}}}`,
+		`{"children":[{"kind":13,"name":"pi","range":{"end":{"character":15,"line":2},"start":{"character":2,"line":2}},"selectionRange":{"end":{"character":8,"line":2},"start":{"character":6,"line":2}}}],"detail":"func(r)","kind":12,"name":"area","range":{"end":{"character":1,"line":4},"start":{"character":0,"line":1}},"selectionRange":{"end":{"character":8,"line":1},"start":{"character":4,"line":1}}}`,
+		`{"detail":"export","kind":13,"name":"unit","range":{"end":{"character":22,"line":5},"start":{"character":0,"line":5}},"selectionRange":{"end":{"character":15,"line":5},"start":{"character":11,"line":5}}}`,
+	}
+	if len(symbols) != len(expectedSymbols) {
+		t.Fatalf("wrong number of symbols. expected=%d, got=%v", len(expectedSymbols), symbols)
+	}
+	for i, expected := range expectedSymbols {
+		if got := toJSON(t, symbols[i]); got != expected {
+			t.Errorf("symbols[%d] is wrong.\nexpected=%s\ngot=     %s", i, expected, got)
+		}
+	}
+
+	expectedEdits := `[{"newText":"import \"math.yz\" as m\nlet area = func(r) {\n  let pi = m.pi\n  pi * r * r\n}\nexport let unit = area(1)\n","range":{"end":{"character":0,"line":6},"start":{"character":0,"line":0}}}]`
+	if got := toJSON(t, replies[2]["result"]); got != expectedEdits {
+		t.Errorf("wrong formatting edits.\nexpected=%s\ngot=     %s", expectedEdits, got)
+	}
+}
+
+func TestConstantSymbols(t *testing.T) {
+	src := "const pi = 3\nexport const e = 2\nconst area = func(r) { pi * r * r }"
+	replies := testSession(t,
+		didOpen(src),
+		request(1, "textDocument/documentSymbol", fmt.Sprintf(`{"textDocument":{"uri":%q}}`, testURI)),
+	)
+
+	symbols := replies[1]["result"].([]interface{})
+	expectedSymbols := []string{
+		`{"kind":14,"name":"pi","range":{"end":{"character":12,"line":0},"start":{"character":0,"line":0}},"selectionRange":{"end":{"character":8,"line":0},"start":{"character":6,"line":0}}}`,
+		`{"detail":"export","kind":14,"name":"e","range":{"end":{"character":18,"line":1},"start":{"character":0,"line":1}},"selectionRange":{"end":{"character":14,"line":1},"start":{"character":13,"line":1}}}`,
+		`{"detail":"func(r)","kind":12,"name":"area","range":{"end":{"character":35,"line":2},"start":{"character":0,"line":2}},"selectionRange":{"end":{"character":10,"line":2},"start":{"character":6,"line":2}}}`,
+	}
+	if len(symbols) != len(expectedSymbols) {
+		t.Fatalf("wrong number of symbols. expected=%d, got=%v", len(expectedSymbols), symbols)
+	}
+	for i, expected := range expectedSymbols {
+		if got := toJSON(t, symbols[i]); got != expected {
+			t.Errorf("symbols[%d] is wrong.\nexpected=%s\ngot=     %s", i, expected, got)
+		}
+	}
+}
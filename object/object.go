@@ -2,8 +2,12 @@ package object
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/shksa/yeezy/ast"
 )
@@ -35,6 +39,13 @@ const (
 	ERROROBJ        = "ERROR"
 	FUNCTION        = "FUNCTION"
 	BUILTINFUNCTION = "BUILTIN_FUNCTION"
+	MODULE          = "MODULE"
+	ARRAY           = "ARRAY"
+	ERRORVALUE      = "ERROR_VALUE"
+	CHANNEL         = "CHANNEL"
+	STRUCTTYPE      = "STRUCT_TYPE"
+	STRUCT          = "STRUCT"
+	HASH            = "HASH"
 )
 
 /* Types in yeezy
@@ -54,6 +65,11 @@ func (i *Integer) Inspect() string { return fmt.Sprintf("%d", i.Value) }
 // Type returns the type's name
 func (i *Integer) Type() string { return INTEGER }
 
+// HashKey returns the key of the integer in hashes.
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: INTEGER, Value: strconv.FormatInt(i.Value, 10)}
+}
+
 // Boolean is type for representing all boolean literal objects in the yeezy lang.
 type Boolean struct {
 	Value bool
@@ -65,6 +81,11 @@ func (b *Boolean) Inspect() string { return fmt.Sprintf("%t", b.Value) }
 // Type returns the type's name
 func (b *Boolean) Type() string { return BOOLEAN }
 
+// HashKey returns the key of the boolean in hashes.
+func (b *Boolean) HashKey() HashKey {
+	return HashKey{Type: BOOLEAN, Value: strconv.FormatBool(b.Value)}
+}
+
 // String is a type for representing all string literal objects in the yeezy lang.
 type String struct {
 	Value string
@@ -76,6 +97,11 @@ func (s *String) Inspect() string { return s.Value }
 // Type returns the type's name
 func (s *String) Type() string { return STRING }
 
+// HashKey returns the key of the string in hashes.
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: STRING, Value: s.Value}
+}
+
 // Null is type for representing the absence of values in yeezy
 type Null struct{} // Does not use Golang's nil to represent null values
 
@@ -97,8 +123,11 @@ func (rv *ReturnValue) Type() string { return RETURNOBJ }
 func (rv *ReturnValue) Inspect() string { return rv.Value.Inspect() }
 
 // Error is a type for representing all errors in yeezy lang.
+// An Error stops the evaluation of the program, unless it is caught by a try expression.
 type Error struct {
 	Message string
+	Stack   []string // calls that were being evaluated when the error happened, the innermost one is first
+	Thrown  bool     // true for the errors raised by a throw statement, false for the errors raised by the interpreter
 }
 
 // Type returns the type's name
@@ -107,12 +136,35 @@ func (e *Error) Type() string { return ERROROBJ }
 // Inspect returns the value in string format
 func (e *Error) Inspect() string { return "Error: " + e.Message }
 
+// ErrorValue is a type for representing an error as a normal value that does not stop the evaluation.
+// A caught Error is turned into an ErrorValue, so that it can be inspected.
+type ErrorValue struct {
+	Message string
+	Stack   []string
+}
+
+// Type returns the type's name
+func (ev *ErrorValue) Type() string { return ERRORVALUE }
+
+// Inspect returns the value in string format
+func (ev *ErrorValue) Inspect() string { return "error: " + ev.Message }
+
 // Environment is a type for representing the interpreter's environment.
+// It is safe for concurrent use, spawned functions share the environments of their closures with the caller.
 type Environment struct {
+	mu       sync.RWMutex
 	store    map[string]Object
 	outerEnv *Environment
+	readOnly bool            // set by Freeze, bindings can no longer be added or changed
+	consts   map[string]bool // names bound by Declare as constants
 }
 
+// The errors Declare returns.
+var (
+	ErrReadOnly = errors.New("the environment is read-only")
+	ErrConstant = errors.New("the name is bound to a constant")
+)
+
 // NewEnvironment returns a pointer to a newly created Environment value.
 func NewEnvironment() *Environment {
 	e := &Environment{store: make(map[string]Object), outerEnv: nil}
@@ -121,21 +173,113 @@ func NewEnvironment() *Environment {
 
 // Get returns the object mapped to a identifier in the current environemnt or in any of the enclosing environments.
 func (e *Environment) Get(name string) (Object, bool) {
+	e.mu.RLock()
 	obj, ok := e.store[name]
+	e.mu.RUnlock()
 	if !ok && e.outerEnv != nil { // If the current env has an outer env, that outer env is checked for the binding.
 		obj, ok = e.outerEnv.Get(name) // Recursively checks all the enclosing envs of the current env to find the binding.
 	}
 	return obj, ok
 }
 
-// Set maps a identifier name to an object
+// Names returns the sorted names of the bindings in the current environment, without the ones of the enclosing environments.
+func (e *Environment) Names() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Outer returns the enclosing environment, or nil for the outermost one.
+func (e *Environment) Outer() *Environment {
+	return e.outerEnv
+}
+
+// Set maps a identifier name to an object, it panics if the environment is read-only.
 func (e *Environment) Set(name string, val Object) Object {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.readOnly {
+		panic("object: Set of " + name + " on a read-only environment")
+	}
 	e.store[name] = val
 	return val
 }
 
+// Declare binds a name to an object in the environment like Set, but it returns ErrReadOnly instead of panicking if the
+// environment is read-only, and ErrConstant if the name is already bound to a constant in this environment. A constant
+// of an enclosing environment can still be shadowed.
+func (e *Environment) Declare(name string, val Object, constant bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.readOnly {
+		return ErrReadOnly
+	}
+	if e.consts[name] {
+		return ErrConstant
+	}
+	if constant {
+		if e.consts == nil {
+			e.consts = make(map[string]bool)
+		}
+		e.consts[name] = true
+	}
+	e.store[name] = val
+	return nil
+}
+
+// IsConstant tells if a name is bound to a constant in the environment, without looking at the enclosing environments.
+func (e *Environment) IsConstant(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.consts[name]
+}
+
+// Freeze makes the environment read-only, so that many goroutines can evaluate programs in environments enclosed by
+// it without one of them changing what the others see. The bindings of enclosing environments are not frozen.
+func (e *Environment) Freeze() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.readOnly = true
+}
+
+// ReadOnly tells if the environment is read-only, see Freeze.
+func (e *Environment) ReadOnly() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.readOnly
+}
+
+// Snapshot returns a read-only environment with the bindings the environment and its enclosing environments have now,
+// later changes to them are not seen by the snapshot. The values themselves are not copied, an array is still shared.
+func (e *Environment) Snapshot() *Environment {
+	snapshot := NewEnvironment()
+	for env := e; env != nil; env = env.outerEnv {
+		env.mu.RLock()
+		for name, val := range env.store {
+			if _, ok := snapshot.store[name]; !ok { // the innermost binding of a name shadows the outer ones
+				snapshot.store[name] = val
+				if env.consts[name] {
+					if snapshot.consts == nil {
+						snapshot.consts = make(map[string]bool)
+					}
+					snapshot.consts[name] = true
+				}
+			}
+		}
+		env.mu.RUnlock()
+	}
+	snapshot.readOnly = true
+	return snapshot
+}
+
 // Function is a type for representing all the function literal values in yeezy.
 type Function struct {
+	Name       string // name of the let statement that made the function, empty for anonymous functions
 	Parameters []*ast.IdentifierNode
 	Body       *ast.BlockStatementNode
 	Env        *Environment // functions carry their environment with them
@@ -176,3 +320,187 @@ func (bf BuiltInFunction) Inspect() string { return "built-in function" }
 
 // Type returns the type's name
 func (bf BuiltInFunction) Type() string { return BUILTINFUNCTION }
+
+// Module is a type for representing an imported yeezy source file and the bindings it exports.
+type Module struct {
+	Name    string            // name the module is bound to by default, the file name without the extention
+	Path    string            // absolute path of the module's source file
+	Exports map[string]Object // exported bindings, accessed as <module>.<name>
+}
+
+// Type returns the type's name
+func (m *Module) Type() string { return MODULE }
+
+// Inspect returns the value in string format
+func (m *Module) Inspect() string { return fmt.Sprintf("module %s (%q)", m.Name, m.Path) }
+
+// Array is a type for representing all array values in yeezy.
+type Array struct {
+	Elements []Object
+}
+
+// Type returns the type's name
+func (a *Array) Type() string { return ARRAY }
+
+// Inspect returns the value in string format, an array that contains itself is shown as [...] inside itself.
+func (a *Array) Inspect() string {
+	return inspect(a, make(map[Object]bool))
+}
+
+// inspect returns a value in string format, outer holds the arrays, structs and hashes that are being inspected around
+// it.
+func inspect(obj Object, outer map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *Hash:
+		if outer[obj] {
+			return "{...}"
+		}
+		outer[obj] = true
+		defer delete(outer, obj)
+
+		pairs := []string{}
+		for _, key := range obj.Keys {
+			pair := obj.Pairs[key]
+			pairs = append(pairs, inspect(pair.Key, outer)+": "+inspect(pair.Value, outer))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+
+	case *Array:
+		if outer[obj] {
+			return "[...]"
+		}
+		outer[obj] = true
+		defer delete(outer, obj)
+
+		elements := []string{}
+		for _, el := range obj.Elements {
+			elements = append(elements, inspect(el, outer))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+
+	case *Struct:
+		if outer[obj] {
+			return obj.StructType.Name + "{...}"
+		}
+		outer[obj] = true
+		defer delete(outer, obj)
+
+		fields := []string{}
+		for idx, field := range obj.StructType.Fields {
+			fields = append(fields, field+": "+inspect(obj.Values[idx], outer))
+		}
+		return obj.StructType.Name + "{" + strings.Join(fields, ", ") + "}"
+	}
+
+	return obj.Inspect()
+}
+
+// Channel is a type for representing the channels that spawned functions send values through.
+type Channel struct {
+	Values chan Object
+}
+
+// NewChannel returns a channel that holds up to capacity values that were sent but not received yet.
+func NewChannel(capacity int) *Channel {
+	return &Channel{Values: make(chan Object, capacity)}
+}
+
+// Type returns the type's name
+func (c *Channel) Type() string { return CHANNEL }
+
+// Inspect returns the value in string format
+func (c *Channel) Inspect() string { return fmt.Sprintf("channel(%d)", cap(c.Values)) }
+
+// StructType is a type for representing the types declared by struct statements, calling one makes a struct.
+type StructType struct {
+	Name   string
+	Fields []string
+}
+
+// Type returns the type's name
+func (st *StructType) Type() string { return STRUCTTYPE }
+
+// Inspect returns the value in string format
+func (st *StructType) Inspect() string {
+	return "struct " + st.Name + " { " + strings.Join(st.Fields, ", ") + " }"
+}
+
+// FieldIndex returns the index of a field in the Fields of the type, and whether the type has the field at all.
+func (st *StructType) FieldIndex(name string) (int, bool) {
+	for idx, field := range st.Fields {
+		if field == name {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// Struct is a type for representing all the struct values in yeezy.
+// Like an array, a struct can be changed by assigning to its fields, it is not safe for concurrent use.
+type Struct struct {
+	StructType *StructType
+	Values     []Object // the values of the fields, in the order of StructType.Fields
+}
+
+// Type returns the type's name
+func (s *Struct) Type() string { return STRUCT }
+
+// Inspect returns the value in string format, like Point{x: 1, y: 2}. A struct that contains itself is shown as
+// Point{...} inside itself.
+func (s *Struct) Inspect() string {
+	return inspect(s, make(map[Object]bool))
+}
+
+// HashKey is a type for representing the keys of hashes, the values that are equal have the same HashKey.
+type HashKey struct {
+	Type  string
+	Value string
+}
+
+// Hashable is an interface for the values that can be the keys of hashes: integers, booleans and strings.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+// HashPair is a type for representing a key of a hash with its value.
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash is a type for representing all the hash values in yeezy, which map integers, booleans and strings to values.
+// A hash keeps its keys in the order they were added, so that it is always shown the same way.
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	Keys  []HashKey // the keys of Pairs, in the order they were added
+}
+
+// NewHash returns an empty hash.
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+// Type returns the type's name
+func (h *Hash) Type() string { return HASH }
+
+// Inspect returns the value in string format, like {a: 1, 2: [3]}. A hash that contains itself is shown as {...} inside
+// itself.
+func (h *Hash) Inspect() string {
+	return inspect(h, make(map[Object]bool))
+}
+
+// Get returns the value of the key in the hash, and whether the hash has the key at all.
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair.Value, ok
+}
+
+// Set sets the value of the key in the hash, a key that is already in it keeps its place in the order of the Keys.
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.Keys = append(h.Keys, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
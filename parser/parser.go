@@ -15,8 +15,10 @@ type Parser struct {
 	curToken              token.Token
 	nextToken             token.Token
 	Errors                []string
+	ErrorTokens           []token.Token // the token each of the Errors was found at
 	ParseFnForPrefixToken map[string]prefixTokenParseFn
 	ParseFnForInfixToken  map[string]infixTokenParseFn
+	blocks                int // number of blocks being parsed, export statements are only allowed outside of them
 }
 
 // New returns a pointer to a newly created Parser object.
@@ -35,6 +37,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerParseFuncForPrefixToken(token.LPAREN, p.parseGroupedExpression)
 	p.registerParseFuncForPrefixToken(token.IF, p.parseIfExpression)
 	p.registerParseFuncForPrefixToken(token.FUNCTION, p.parseFunctionLiteralExpression)
+	p.registerParseFuncForPrefixToken(token.LBRACKET, p.parseArrayLiteral)
+	p.registerParseFuncForPrefixToken(token.LBRACE, p.parseHashLiteral)
+	p.registerParseFuncForPrefixToken(token.TRY, p.parseTryExpression)
+	p.registerParseFuncForPrefixToken(token.SPAWN, p.parseSpawnExpression)
+	p.registerParseFuncForPrefixToken(token.SELECT, p.parseSelectExpression)
+	p.registerParseFuncForPrefixToken(token.MATCH, p.parseMatchExpression)
 	p.ParseFnForInfixToken = make(map[string]infixTokenParseFn)
 	p.registerParseFuncForInfixToken(token.PLUS, p.parseInfixExpression)
 	p.registerParseFuncForInfixToken(token.MINUS, p.parseInfixExpression)
@@ -45,6 +53,9 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerParseFuncForInfixToken(token.NOTEQ, p.parseInfixExpression)
 	p.registerParseFuncForInfixToken(token.LPAREN, p.parseCallExpression)
 	p.registerParseFuncForInfixToken(token.SLASH, p.parseInfixExpression)
+	p.registerParseFuncForInfixToken(token.DOT, p.parseMemberExpression)
+	p.registerParseFuncForInfixToken(token.LBRACKET, p.parseIndexExpression)
+	p.registerParseFuncForInfixToken(token.QUESTION, p.parsePropagateExpression)
 	return p
 }
 
@@ -68,12 +79,24 @@ func (p *Parser) registerParseFuncForInfixToken(tok token.Token, fn infixTokenPa
 
 func (p *Parser) noPrefixParseFnError(tok token.Token) {
 	msg := fmt.Sprintf("No prefix parse function found for %s token", tok.Literal)
+	p.addError(tok, msg)
+}
+
+// addError records a parse error found at the given token.
+func (p *Parser) addError(tok token.Token, msg string) {
 	p.Errors = append(p.Errors, msg)
+	p.ErrorTokens = append(p.ErrorTokens, tok)
 }
 
+// readNextToken moves to the next token. A string without its closing quote is reported once, when it is read, and is
+// then parsed as a string.
 func (p *Parser) readNextToken() {
 	p.curToken = p.nextToken
 	p.nextToken = p.l.NextToken()
+	if p.nextToken.Type == token.UNTERMINATED.Type {
+		p.addError(p.nextToken, "unterminated string, it has no closing quote")
+		p.nextToken.Type = token.STRING.Type
+	}
 }
 
 // ParseProgram parses the whole program in a top-down recursive way to
@@ -111,11 +134,19 @@ IMPORTANT:
 // parseStatement parses statements based on the current token info.
 // Because the type of a statement is determined by it's FIRST token.
 func (p *Parser) parseStatement() ast.StatementNode {
-	switch p.curToken {
-	case token.LET:
+	switch p.curToken.Type {
+	case token.LET.Type, token.CONST.Type:
 		return p.parseLetStatement()
-	case token.RETURN:
+	case token.RETURN.Type:
 		return p.parseReturnStatement()
+	case token.IMPORT.Type:
+		return p.parseImportStatement()
+	case token.EXPORT.Type:
+		return p.parseExportStatement()
+	case token.THROW.Type:
+		return p.parseThrowStatement()
+	case token.STRUCT.Type:
+		return p.parseStructStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -130,7 +161,7 @@ func (p *Parser) expectAndReadNextTokenToBe(tok token.Token) bool {
 	return true
 }
 
-// parseLetStatement returns a pointer to a let statement node i.e *ast.LetStatementNode
+// parseLetStatement returns a pointer to a let statement node i.e *ast.LetStatementNode, for `let` as well as `const`.
 func (p *Parser) parseLetStatement() *ast.LetStatementNode {
 	letStmt := &ast.LetStatementNode{Token: p.curToken}
 
@@ -166,7 +197,7 @@ func (p *Parser) nextTokenIs(tok token.Token) bool {
 
 func (p *Parser) unexpectedTokenError(expectedTok token.Token) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead", expectedTok.Literal, p.nextToken.Literal)
-	p.Errors = append(p.Errors, msg)
+	p.addError(p.nextToken, msg)
 }
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatementNode {
@@ -183,11 +214,148 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatementNode {
 	return retStmt
 }
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatementNode {
+// parseImportStatement returns a statement node for `import "path/to/lib.yz"` with an optional `as <identifier>` clause.
+func (p *Parser) parseImportStatement() ast.StatementNode {
+	importStmt := &ast.ImportStatementNode{Token: p.curToken}
+
+	if isRead := p.expectAndReadNextTokenToBe(token.STRING); !isRead {
+		return nil
+	}
+
+	importStmt.Path = &ast.StringLiteralNode{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.nextTokenIs(token.AS) {
+		p.readNextToken()
+		if isRead := p.expectAndReadNextTokenToBe(token.IDENTIFIER); !isRead {
+			return nil
+		}
+		importStmt.Alias = &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+	}
+
+	if p.nextTokenIs(token.SEMICOLON) {
+		p.readNextToken()
+	}
+
+	return importStmt
+}
+
+// parseExportStatement returns a statement node for `export let <identifier> = <expression>`, or for `export const`.
+// Only the top level of a module can export, so an export in a block is an error.
+func (p *Parser) parseExportStatement() ast.StatementNode {
+	exportStmt := &ast.ExportStatementNode{Token: p.curToken}
+	if p.blocks > 0 {
+		p.addError(p.curToken, "export is only allowed at the top level of a module")
+	}
+
+	if p.nextTokenIs(token.CONST) {
+		p.readNextToken()
+	} else if isRead := p.expectAndReadNextTokenToBe(token.LET); !isRead {
+		return nil
+	}
+
+	letStmt := p.parseLetStatement()
+	if letStmt == nil || p.blocks > 0 {
+		return nil
+	}
+	exportStmt.Statement = letStmt
+
+	return exportStmt
+}
+
+func (p *Parser) parseThrowStatement() ast.StatementNode {
+	throwStmt := &ast.ThrowStatementNode{Token: p.curToken}
+
+	p.readNextToken()
+
+	throwStmt.Value = p.parseExpression(LOWEST)
+	if throwStmt.Value == nil {
+		return nil
+	}
+
+	if p.nextTokenIs(token.SEMICOLON) {
+		p.readNextToken()
+	}
+
+	return throwStmt
+}
+
+// parseStructStatement parses `struct <name> { <field>, <field>, ... }`.
+func (p *Parser) parseStructStatement() ast.StatementNode {
+	structStmt := &ast.StructStatementNode{Token: p.curToken}
+
+	if isRead := p.expectAndReadNextTokenToBe(token.IDENTIFIER); !isRead {
+		return nil
+	}
+	structStmt.Name = &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+
+	if isRead := p.expectAndReadNextTokenToBe(token.LBRACE); !isRead {
+		return nil
+	}
+
+	for !p.nextTokenIs(token.RBRACE) {
+		if len(structStmt.Fields) > 0 {
+			if isRead := p.expectAndReadNextTokenToBe(token.COMMA); !isRead {
+				return nil
+			}
+		}
+		if isRead := p.expectAndReadNextTokenToBe(token.IDENTIFIER); !isRead {
+			return nil
+		}
+		field := &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+		for _, other := range structStmt.Fields {
+			if other.Name == field.Name {
+				p.addError(p.curToken, fmt.Sprintf("duplicate field %s in struct %s", field.Name, structStmt.Name.Name))
+			}
+		}
+		structStmt.Fields = append(structStmt.Fields, field)
+	}
+	p.readNextToken()
+	structStmt.EndToken = p.curToken
+
+	if p.nextTokenIs(token.SEMICOLON) {
+		p.readNextToken()
+	}
+
+	return structStmt
+}
+
+// parseAssignStatement parses `<object>.<field> = <expression>`, the target was parsed as the expression of an
+// expression statement that starts with the start token, it is nil if it could not be parsed.
+func (p *Parser) parseAssignStatement(start token.Token, target ast.ExpressionNode) ast.StatementNode {
+	member, ok := target.(*ast.MemberExpressionNode)
+	if !ok && target != nil {
+		p.addError(p.nextToken, fmt.Sprintf("cannot assign to %s, only to the field of a struct", target.String()))
+	}
+
+	p.readNextToken()
+	p.readNextToken()
+
+	assignStmt := &ast.AssignStatementNode{Token: start, Target: member}
+	assignStmt.Value = p.parseExpression(LOWEST)
+
+	if p.nextTokenIs(token.SEMICOLON) {
+		p.readNextToken()
+	}
+
+	if !ok || assignStmt.Value == nil {
+		return nil
+	}
+	return assignStmt
+}
+
+func (p *Parser) parseExpressionStatement() ast.StatementNode {
 	exprStmtNode := &ast.ExpressionStatementNode{Token: p.curToken}
 
+	errorCount := len(p.Errors)
 	exprStmtNode.Expression = p.parseExpression(LOWEST)
 
+	if p.nextTokenIs(token.ASSIGN) {
+		if len(p.Errors) > errorCount { // The target has parse errors, which are reported already.
+			return p.parseAssignStatement(exprStmtNode.Token, nil)
+		}
+		return p.parseAssignStatement(exprStmtNode.Token, exprStmtNode.Expression)
+	}
+
 	// semicolon is optional for single-line inputs.
 	// 1.	For a single-line input without a semicolon at the end,
 	//			p.curToken will be the last token of the expression. ex: } for if-expr.
@@ -222,19 +390,23 @@ const (
 	SUM             // +, #4
 	PRODUCT         // *, #5
 	PREFIX          // -X or !X, #6
-	CALL            // myFunction(X), #7
+	CALL            // myFunction(X) or X?, #7
+	MEMBER          // lib.name or myArray[X], #8
 )
 
-var precedences = map[token.Token]int{
-	token.EQ:       EQUALS,      // 2
-	token.NOTEQ:    EQUALS,      // 2
-	token.LT:       LESSGREATER, // 3
-	token.GT:       LESSGREATER, // 3
-	token.PLUS:     SUM,         // 4
-	token.MINUS:    SUM,         // 4
-	token.SLASH:    PRODUCT,     // 5
-	token.ASTERISK: PRODUCT,     // 5
-	token.LPAREN:   CALL,        // 7
+var precedences = map[string]int{
+	token.EQ.Type:       EQUALS,      // 2
+	token.NOTEQ.Type:    EQUALS,      // 2
+	token.LT.Type:       LESSGREATER, // 3
+	token.GT.Type:       LESSGREATER, // 3
+	token.PLUS.Type:     SUM,         // 4
+	token.MINUS.Type:    SUM,         // 4
+	token.SLASH.Type:    PRODUCT,     // 5
+	token.ASTERISK.Type: PRODUCT,     // 5
+	token.LPAREN.Type:   CALL,        // 7
+	token.QUESTION.Type: CALL,        // 7
+	token.DOT.Type:      MEMBER,      // 8
+	token.LBRACKET.Type: MEMBER,      // 8
 }
 
 // parseExpression does the following:-
@@ -276,7 +448,48 @@ func (p *Parser) parseExpression(precedence int) ast.ExpressionNode {
 }
 
 func (p *Parser) parseIdentifier() ast.ExpressionNode {
-	return &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+	iden := &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+	if p.nextTokenIs(token.LBRACE) { // An identifier is never followed by a block, so this is a struct literal.
+		return p.parseStructLiteral(iden)
+	}
+	return iden
+}
+
+// parseStructLiteral parses `<type>{<field>: <expression>, ...}`, p.curToken is the type.
+func (p *Parser) parseStructLiteral(structType *ast.IdentifierNode) ast.ExpressionNode {
+	p.readNextToken()
+	literal := &ast.StructLiteralNode{Token: p.curToken, Type: structType}
+
+	for !p.nextTokenIs(token.RBRACE) {
+		if len(literal.Fields) > 0 {
+			if isRead := p.expectAndReadNextTokenToBe(token.COMMA); !isRead {
+				return nil
+			}
+		}
+		if isRead := p.expectAndReadNextTokenToBe(token.IDENTIFIER); !isRead {
+			return nil
+		}
+		field := &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+		for _, other := range literal.Fields {
+			if other.Name == field.Name {
+				p.addError(p.curToken, fmt.Sprintf("duplicate field %s in %s{...}", field.Name, structType.Name))
+			}
+		}
+		if isRead := p.expectAndReadNextTokenToBe(token.COLON); !isRead {
+			return nil
+		}
+		p.readNextToken()
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+		literal.Fields = append(literal.Fields, field)
+		literal.Values = append(literal.Values, value)
+	}
+	p.readNextToken()
+	literal.EndToken = p.curToken
+
+	return literal // p.curToken is at "}" now
 }
 
 func (p *Parser) parseIntegerLiteral() ast.ExpressionNode {
@@ -284,7 +497,7 @@ func (p *Parser) parseIntegerLiteral() ast.ExpressionNode {
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
 		errMsg := fmt.Sprintf("cannot parse %q as an int64", p.curToken.Literal)
-		p.Errors = append(p.Errors, errMsg)
+		p.addError(p.curToken, errMsg)
 		return nil
 	}
 	intLiteralNode.Value = value
@@ -302,14 +515,14 @@ func (p *Parser) parsePrefixExpression() ast.ExpressionNode {
 }
 
 func (p *Parser) curTokenPrecedence() int {
-	if p, ok := precedences[p.curToken]; ok {
+	if p, ok := precedences[p.curToken.Type]; ok {
 		return p
 	}
 	return LOWEST
 }
 
 func (p *Parser) nextTokenPrecedence() int {
-	if p, ok := precedences[p.nextToken]; ok {
+	if p, ok := precedences[p.nextToken.Type]; ok {
 		return p
 	}
 	return LOWEST // This is returned when the p.nextToken is a token.EOF or token.SEMICOLON.
@@ -382,12 +595,366 @@ func (p *Parser) parseIfExpression() ast.ExpressionNode {
 	return ifExpr // p.curToken is at "}" now
 }
 
+func (p *Parser) parseTryExpression() ast.ExpressionNode {
+	tryExpr := &ast.TryExpressionNode{Token: p.curToken}
+
+	if isRead := p.expectAndReadNextTokenToBe(token.LBRACE); !isRead {
+		return nil
+	}
+
+	tryExpr.Block = p.parseBlockStatement()
+
+	if p.nextTokenIs(token.CATCH) {
+		p.readNextToken()
+
+		if p.nextTokenIs(token.LPAREN) {
+			p.readNextToken()
+			if isRead := p.expectAndReadNextTokenToBe(token.IDENTIFIER); !isRead {
+				return nil
+			}
+			tryExpr.CatchParam = &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+			if isRead := p.expectAndReadNextTokenToBe(token.RPAREN); !isRead {
+				return nil
+			}
+		}
+
+		if isRead := p.expectAndReadNextTokenToBe(token.LBRACE); !isRead {
+			return nil
+		}
+
+		tryExpr.Catch = p.parseBlockStatement()
+	}
+
+	if p.nextTokenIs(token.FINALLY) {
+		p.readNextToken()
+
+		if isRead := p.expectAndReadNextTokenToBe(token.LBRACE); !isRead {
+			return nil
+		}
+
+		tryExpr.Finally = p.parseBlockStatement()
+	}
+
+	if tryExpr.Catch == nil && tryExpr.Finally == nil {
+		p.addError(tryExpr.Token, "expected catch or finally after try block")
+		return nil
+	}
+
+	return tryExpr // p.curToken is at "}" now
+}
+
+// parseSpawnExpression parses `spawn <call>`, the call binds tighter than spawn, like the operand of a prefix operator.
+func (p *Parser) parseSpawnExpression() ast.ExpressionNode {
+	spawnExpr := &ast.SpawnExpressionNode{Token: p.curToken}
+
+	p.readNextToken()
+
+	call, ok := p.parseExpression(PREFIX).(*ast.CallExpressionNode)
+	if !ok {
+		p.addError(spawnExpr.Token, "expected a function call after spawn")
+		return nil
+	}
+	spawnExpr.Call = call
+
+	return spawnExpr // p.curToken is ")"
+}
+
+// parseSelectExpression parses `select { case ... { <block> } default { <block> } }`.
+func (p *Parser) parseSelectExpression() ast.ExpressionNode {
+	selectExpr := &ast.SelectExpressionNode{Token: p.curToken}
+
+	if isRead := p.expectAndReadNextTokenToBe(token.LBRACE); !isRead {
+		return nil
+	}
+
+	for !p.nextTokenIs(token.RBRACE) {
+		switch p.nextToken.Type {
+		case token.CASE.Type:
+			p.readNextToken()
+			selectCase := p.parseSelectCase()
+			if selectCase == nil {
+				return nil
+			}
+			selectExpr.Cases = append(selectExpr.Cases, selectCase)
+
+		case token.DEFAULT.Type:
+			p.readNextToken()
+			if selectExpr.Default != nil {
+				p.addError(p.curToken, "select has more than one default case")
+				return nil
+			}
+			if isRead := p.expectAndReadNextTokenToBe(token.LBRACE); !isRead {
+				return nil
+			}
+			selectExpr.Default = p.parseBlockStatement()
+
+		case token.EOF.Type:
+			p.addError(p.nextToken, "expected } to close the select, got end of input instead")
+			return nil
+
+		default:
+			p.addError(p.nextToken, fmt.Sprintf("expected case or default in select, got %s instead", p.nextToken.Literal))
+			return nil
+		}
+	}
+	p.readNextToken()
+	selectExpr.EndToken = p.curToken
+
+	if len(selectExpr.Cases) == 0 && selectExpr.Default == nil {
+		p.addError(selectExpr.Token, "expected a case in select")
+		return nil
+	}
+
+	return selectExpr // p.curToken is at "}" now
+}
+
+// parseSelectCase parses `case <name> = receive(<channel>) { <block> }` or `case send(<channel>, <value>) { <block> }`,
+// the name can be left out of a receive case.
+func (p *Parser) parseSelectCase() *ast.SelectCaseNode {
+	selectCase := &ast.SelectCaseNode{Token: p.curToken}
+
+	p.readNextToken()
+
+	if p.curTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.ASSIGN) {
+		selectCase.Name = &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+		p.readNextToken()
+		p.readNextToken()
+	}
+
+	call, ok := p.parseExpression(LOWEST).(*ast.CallExpressionNode)
+	operation := ""
+	if ok {
+		if iden, isIden := call.Function.(*ast.IdentifierNode); isIden {
+			operation = iden.Name
+		}
+	}
+	switch {
+	case operation == "receive" && len(call.Arguments) == 1:
+	case operation == "send" && len(call.Arguments) == 2 && selectCase.Name == nil:
+	default:
+		p.addError(selectCase.Token, "expected receive(channel) or send(channel, value) after case")
+		return nil
+	}
+	selectCase.Operation = call
+
+	if isRead := p.expectAndReadNextTokenToBe(token.LBRACE); !isRead {
+		return nil
+	}
+
+	selectCase.Body = p.parseBlockStatement()
+
+	return selectCase // p.curToken is at "}" now
+}
+
+// parseMatchExpression parses `match (<expression>) { <pattern> => <expression>, <pattern> => { <block> } ... }`, the
+// comma after an arm can be left out when the arm ends with a block.
+func (p *Parser) parseMatchExpression() ast.ExpressionNode {
+	matchExpr := &ast.MatchExpressionNode{Token: p.curToken}
+
+	if isRead := p.expectAndReadNextTokenToBe(token.LPAREN); !isRead {
+		return nil
+	}
+	p.readNextToken()
+	matchExpr.Value = p.parseExpression(LOWEST)
+	if matchExpr.Value == nil {
+		return nil
+	}
+	if isRead := p.expectAndReadNextTokenToBe(token.RPAREN); !isRead {
+		return nil
+	}
+	if isRead := p.expectAndReadNextTokenToBe(token.LBRACE); !isRead {
+		return nil
+	}
+
+	for !p.nextTokenIs(token.RBRACE) {
+		if p.nextTokenIs(token.EOF) {
+			p.addError(p.nextToken, "expected } to close the match, got end of input instead")
+			return nil
+		}
+		p.readNextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		matchExpr.Arms = append(matchExpr.Arms, arm)
+
+		if p.nextTokenIs(token.COMMA) {
+			p.readNextToken()
+		} else if arm.Body == nil && !p.nextTokenIs(token.RBRACE) && !p.nextTokenIs(token.EOF) {
+			p.unexpectedTokenError(token.COMMA)
+			return nil
+		}
+	}
+	p.readNextToken()
+	matchExpr.EndToken = p.curToken
+
+	if len(matchExpr.Arms) == 0 {
+		p.addError(matchExpr.Token, "expected an arm in match")
+		return nil
+	}
+
+	return matchExpr // p.curToken is at "}" now
+}
+
+// parseMatchArm parses `<pattern> => <expression>` or `<pattern> => { <block> }`, p.curToken is the first token of the
+// pattern.
+func (p *Parser) parseMatchArm() *ast.MatchArmNode {
+	arm := &ast.MatchArmNode{Token: p.curToken}
+
+	errorCount := len(p.Errors)
+	arm.Pattern = p.parsePattern()
+	if arm.Pattern == nil || len(p.Errors) > errorCount {
+		return nil
+	}
+	bound := map[string]bool{}
+	for _, binding := range arm.Bindings() {
+		if bound[binding.Name] {
+			p.addError(binding.Token, fmt.Sprintf("%s is bound more than once in the pattern", binding.Name))
+		}
+		bound[binding.Name] = true
+	}
+
+	if isRead := p.expectAndReadNextTokenToBe(token.ARROW); !isRead {
+		return nil
+	}
+	p.readNextToken()
+
+	if p.curTokenIs(token.LBRACE) {
+		arm.Body = p.parseBlockStatement()
+	} else {
+		arm.Value = p.parseExpression(LOWEST)
+		if arm.Value == nil {
+			return nil
+		}
+	}
+
+	return arm // p.curToken is the last token of the arm
+}
+
+// parsePattern parses the pattern of a match arm, p.curToken is its first token. Patterns are literals, negative
+// integers, identifiers, and arrays, struct literals and hash patterns of patterns.
+func (p *Parser) parsePattern() ast.ExpressionNode {
+	switch p.curToken.Type {
+	case token.INT.Type:
+		return p.parseIntegerLiteral()
+
+	case token.STRING.Type:
+		return p.parseStringLiteral()
+
+	case token.TRUE.Type, token.FALSE.Type:
+		return p.parseBooleanLiteral()
+
+	case token.MINUS.Type: // a negative integer is a single literal in a pattern, there is nothing to negate
+		minus := p.curToken
+		if isRead := p.expectAndReadNextTokenToBe(token.INT); !isRead {
+			return nil
+		}
+		p.curToken.Literal = minus.Literal + p.curToken.Literal
+		p.curToken.Line, p.curToken.Column = minus.Line, minus.Column
+		return p.parseIntegerLiteral()
+
+	case token.IDENTIFIER.Type:
+		iden := &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+		if !p.nextTokenIs(token.LBRACE) {
+			return iden
+		}
+		p.readNextToken()
+		literal := &ast.StructLiteralNode{Token: p.curToken, Type: iden}
+		literal.Fields, literal.Values = p.parseFieldPatterns(token.IDENTIFIER, "duplicate field %s in "+iden.Name+"{...}")
+		if literal.Values == nil {
+			return nil
+		}
+		literal.EndToken = p.curToken
+		return literal
+
+	case token.LBRACE.Type:
+		hashPattern := &ast.HashPatternNode{Token: p.curToken}
+		keys, values := p.parseFieldPatterns(token.STRING, "duplicate key %q in the hash pattern")
+		if values == nil {
+			return nil
+		}
+		for _, key := range keys {
+			hashPattern.Keys = append(hashPattern.Keys, &ast.StringLiteralNode{Token: key.Token, Value: key.Name})
+		}
+		hashPattern.Values = values
+		hashPattern.EndToken = p.curToken
+		return hashPattern
+
+	case token.LBRACKET.Type:
+		arrayLiteral := &ast.ArrayLiteralNode{Token: p.curToken, Elements: []ast.ExpressionNode{}}
+		for !p.nextTokenIs(token.RBRACKET) {
+			if len(arrayLiteral.Elements) > 0 {
+				if isRead := p.expectAndReadNextTokenToBe(token.COMMA); !isRead {
+					return nil
+				}
+			}
+			p.readNextToken()
+			element := p.parsePattern()
+			if element == nil {
+				return nil
+			}
+			arrayLiteral.Elements = append(arrayLiteral.Elements, element)
+		}
+		p.readNextToken()
+		arrayLiteral.EndToken = p.curToken
+		return arrayLiteral // p.curToken is token.RBRACKET "]"
+
+	default:
+		p.addError(p.curToken, fmt.Sprintf("expected a pattern, got %s instead", p.curToken.Literal))
+		return nil
+	}
+}
+
+// parseFieldPatterns parses `{<key>: <pattern>, ...}` for struct and hash patterns, p.curToken is "{". The keys are
+// tokens of keyType, returned as identifiers, and a key given twice is reported with duplicateKeyMsg. The values are nil
+// when the patterns could not be parsed.
+func (p *Parser) parseFieldPatterns(keyType token.Token, duplicateKeyMsg string) ([]*ast.IdentifierNode, []ast.ExpressionNode) {
+	keys := []*ast.IdentifierNode{}
+	values := []ast.ExpressionNode{}
+
+	for !p.nextTokenIs(token.RBRACE) {
+		if len(keys) > 0 {
+			if isRead := p.expectAndReadNextTokenToBe(token.COMMA); !isRead {
+				return nil, nil
+			}
+		}
+		if isRead := p.expectAndReadNextTokenToBe(keyType); !isRead {
+			return nil, nil
+		}
+		key := &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+		for _, other := range keys {
+			if other.Name == key.Name {
+				p.addError(p.curToken, fmt.Sprintf(duplicateKeyMsg, key.Name))
+			}
+		}
+		if isRead := p.expectAndReadNextTokenToBe(token.COLON); !isRead {
+			return nil, nil
+		}
+		p.readNextToken()
+		value := p.parsePattern()
+		if value == nil {
+			return nil, nil
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	p.readNextToken()
+
+	return keys, values // p.curToken is at "}" now
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatementNode {
 	blockStmt := &ast.BlockStatementNode{Token: p.curToken}
+	p.blocks++
+	defer func() { p.blocks-- }()
 
 	p.readNextToken()
 
 	for !p.curTokenIs(token.RBRACE) {
+		if p.curTokenIs(token.EOF) {
+			p.addError(p.curToken, "expected } to close the block, got end of input instead")
+			return blockStmt
+		}
 		stmtNode := p.parseStatement()
 		if stmtNode != nil {
 			blockStmt.Statements = append(blockStmt.Statements, stmtNode)
@@ -395,6 +962,8 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatementNode {
 		p.readNextToken()
 	}
 
+	blockStmt.EndToken = p.curToken
+
 	return blockStmt // p.curToken is at "}" now
 }
 
@@ -454,15 +1023,24 @@ func (p *Parser) parseCallExpression(function ast.ExpressionNode) ast.Expression
 	callExp := &ast.CallExpressionNode{Token: p.curToken, Function: function}
 
 	callExp.Arguments = p.parseCallArguments()
+	if callExp.Arguments != nil {
+		callExp.EndToken = p.curToken
+	}
 
 	return callExp
 }
 
 func (p *Parser) parseCallArguments() []ast.ExpressionNode {
 	// p.curToken is "("
+	return p.parseExpressionList(token.RPAREN) // p.curToken is token.RPAREN ")"
+}
+
+// parseExpressionList parses comma separated expressions till the end token, which closes the list.
+func (p *Parser) parseExpressionList(end token.Token) []ast.ExpressionNode {
+	// p.curToken is the token that opens the list, "(" or "["
 	args := []ast.ExpressionNode{}
 
-	if p.nextTokenIs(token.RPAREN) {
+	if p.nextTokenIs(end) {
 		p.readNextToken()
 		return args
 	}
@@ -487,11 +1065,85 @@ func (p *Parser) parseCallArguments() []ast.ExpressionNode {
 		}
 	}
 
-	if isRead := p.expectAndReadNextTokenToBe(token.RPAREN); !isRead {
+	if isRead := p.expectAndReadNextTokenToBe(end); !isRead {
+		return nil
+	}
+
+	return args // p.curToken is the end token
+}
+
+func (p *Parser) parseArrayLiteral() ast.ExpressionNode {
+	arrayLiteral := &ast.ArrayLiteralNode{Token: p.curToken}
+
+	arrayLiteral.Elements = p.parseExpressionList(token.RBRACKET)
+	if arrayLiteral.Elements != nil {
+		arrayLiteral.EndToken = p.curToken
+	}
+
+	return arrayLiteral // p.curToken is token.RBRACKET "]"
+}
+
+// parseHashLiteral parses `{<key>: <expression>, ...}`, p.curToken is "{". The keys are expressions too.
+func (p *Parser) parseHashLiteral() ast.ExpressionNode {
+	hashLiteral := &ast.HashLiteralNode{Token: p.curToken}
+
+	for !p.nextTokenIs(token.RBRACE) {
+		if len(hashLiteral.Keys) > 0 {
+			if isRead := p.expectAndReadNextTokenToBe(token.COMMA); !isRead {
+				return nil
+			}
+		}
+		p.readNextToken()
+		key := p.parseExpression(LOWEST)
+		if key == nil {
+			return nil
+		}
+		if isRead := p.expectAndReadNextTokenToBe(token.COLON); !isRead {
+			return nil
+		}
+		p.readNextToken()
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+		hashLiteral.Keys = append(hashLiteral.Keys, key)
+		hashLiteral.Values = append(hashLiteral.Values, value)
+	}
+	p.readNextToken()
+	hashLiteral.EndToken = p.curToken
+
+	return hashLiteral // p.curToken is at "}" now
+}
+
+func (p *Parser) parseIndexExpression(left ast.ExpressionNode) ast.ExpressionNode {
+	indexExpr := &ast.IndexExpressionNode{Token: p.curToken, Left: left}
+
+	p.readNextToken()
+	indexExpr.Index = p.parseExpression(LOWEST)
+
+	if isRead := p.expectAndReadNextTokenToBe(token.RBRACKET); !isRead {
+		return nil
+	}
+	indexExpr.EndToken = p.curToken
+
+	return indexExpr // p.curToken is token.RBRACKET "]"
+}
+
+func (p *Parser) parseMemberExpression(object ast.ExpressionNode) ast.ExpressionNode {
+	memberExpr := &ast.MemberExpressionNode{Token: p.curToken, Object: object}
+
+	if isRead := p.expectAndReadNextTokenToBe(token.IDENTIFIER); !isRead {
 		return nil
 	}
 
-	return args // p.curToken is token.RPAREN ")"
+	memberExpr.Property = &ast.IdentifierNode{Token: p.curToken, Name: p.curToken.Literal}
+
+	return memberExpr // p.curToken is the property identifier
+}
+
+// parsePropagateExpression parses the postfix "?" operator, the token is in infix position but has no right operand.
+func (p *Parser) parsePropagateExpression(value ast.ExpressionNode) ast.ExpressionNode {
+	return &ast.PropagateExpressionNode{Token: p.curToken, Value: value} // p.curToken is "?"
 }
 
 func (p *Parser) parseStringLiteral() ast.ExpressionNode {
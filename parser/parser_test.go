@@ -2,6 +2,9 @@ package parser
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/shksa/yeezy/ast"
@@ -73,7 +76,7 @@ func TestReturnStatements(t *testing.T) {
 		{"return 5", "5"},
 		{"return true", "true"},
 		{"return foo", "foo"},
-		{`return "foo"`, "foo"},
+		{`return "foo"`, `"foo"`},
 		{"return add(1 + 2, 3, 4)", "add((1 + 2), 3, 4)"},
 	}
 
@@ -486,6 +489,30 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g));",
 		},
+		{
+			"-lib.x * lib.add(1, 2)",
+			"((-lib.x) * lib.add(1, 2));",
+		},
+		{
+			"a.b.c(d)",
+			"a.b.c(d);",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d);",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])));",
+		},
+		{
+			"a + parse(b)? * -c?",
+			"(a + ((parse(b)?) * (-(c?))));",
+		},
+		{
+			"arr.push(1)[0].upper()",
+			"(arr.push(1)[0]).upper();",
+		},
 	}
 
 	for _, tt := range tests {
@@ -778,3 +805,414 @@ func TestStringLiteralExpression(t *testing.T) {
 
 	testStringLiteral(t, empStmt.Expression, "foo bar")
 }
+
+func TestImportAndExportStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "lib/math.yz" as math`, `import "lib/math.yz" as math;`},
+		{`import "math.yz";`, `import "math.yz";`},
+		{`export let x = 5`, `export let x = 5;`},
+		{`export const x = 5`, `export const x = 5;`},
+		{`const x = 5`, `const x = 5;`},
+		{`export let add = func(a, b) { a + b };`, `export let add = func(a, b) {(a + b);};`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	for _, input := range []string{
+		"let f = func() { export let leaked = 42; 1 }",
+		"if (true) { export const x = 1 }",
+		"try { 1 } catch (e) { export let err = e }",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		expected := []string{"export is only allowed at the top level of a module"}
+		if len(p.Errors) != 1 || p.Errors[0] != expected[0] {
+			t.Errorf("%s: expected the parse errors %q, got=%q", input, expected, p.Errors)
+		}
+	}
+}
+
+func TestMemberExpression(t *testing.T) {
+	l := lexer.New("lib.name")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatementNode)
+	memberExpr, ok := stmt.Expression.(*ast.MemberExpressionNode)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.MemberExpressionNode. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, memberExpr.Object, "lib") {
+		return
+	}
+
+	if !testIdentifier(t, memberExpr.Property, "name") {
+		return
+	}
+}
+
+func TestArrayLiteralExpression(t *testing.T) {
+	l := lexer.New("[1, 2 * 2, 3 + 3]")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatementNode)
+	array, ok := stmt.Expression.(*ast.ArrayLiteralNode)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.ArrayLiteralNode. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestIndexExpression(t *testing.T) {
+	l := lexer.New("myArray[1 + 1]")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatementNode)
+	indexExpr, ok := stmt.Expression.(*ast.IndexExpressionNode)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.IndexExpressionNode. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, indexExpr.Left, "myArray") {
+		return
+	}
+
+	testInfixExpression(t, indexExpr.Index, 1, "+", 1)
+}
+
+func TestTryExpressionAndThrowStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`throw "bad"`, `throw "bad";`},
+		{`try { x } catch (e) { e }`, `try {x;} catch (e) {e;};`},
+		{`try { x } catch { 1 } finally { 2 }`, `try {x;} catch {1;} finally {2;};`},
+		{`try { x } finally { y }`, `try {x;} finally {y;};`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New(`try { x }`))
+	p.ParseProgram()
+	if len(p.Errors) == 0 {
+		t.Errorf("expected a parse error for a try expression without catch or finally")
+	}
+}
+
+func TestSpawnAndSelectExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`spawn worker(jobs, 1)`, `(spawn worker(jobs, 1));`},
+		{`spawn lib.run()`, `(spawn lib.run());`},
+		{`spawn func() { 1 }()`, `(spawn func() {1;}());`},
+		{`spawn f(x) == y`, `((spawn f(x)) == y);`},
+		{`select { case v = receive(ch) { v } }`, `select {case v = receive(ch) {v;} };`},
+		{"select {\n  case receive(done) { 0 }\n  case send(out, 1 + 2) { 1 }\n  default { 2 }\n}", `select {case receive(done) {0;} case send(out, (1 + 2)) {1;} default {2;}};`},
+		{`select { default { 1 } }`, `select {default {1;}};`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`spawn f`, "expected a function call after spawn"},
+		{`spawn 1 + f()`, "expected a function call after spawn"},
+		{`select {}`, "expected a case in select"},
+		{`select { x }`, "expected case or default in select, got x instead"},
+		{`select { case v = send(ch, 1) { v } }`, "expected receive(channel) or send(channel, value) after case"},
+		{`select { case receive(a, b) { 1 } }`, "expected receive(channel) or send(channel, value) after case"},
+		{`select { case f(ch) { 1 } }`, "expected receive(channel) or send(channel, value) after case"},
+		{`select { default { 1 } default { 2 } }`, "select has more than one default case"},
+		{`select { case receive(ch) { 1 }`, "expected } to close the select, got end of input instead"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors) == 0 || p.Errors[0] != tt.expectedError {
+			t.Errorf("%s: expected the parse error %q, got=%q", tt.input, tt.expectedError, p.Errors)
+		}
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`struct Point { x, y }`, `struct Point {x, y};`},
+		{"struct Unit {};\nUnit()", `struct Unit {};Unit();`},
+		{`Point{x: 1 + 2, y: f(3)}`, `Point{x: (1 + 2), y: f(3)};`},
+		{`Point{}.x`, `Point{}.x;`},
+		{`Point{x: 1} == p`, `(Point{x: 1} == p);`},
+		{`p.x = p.x + 1`, `p.x = (p.x + 1);`},
+		{"lines[0].start.x = -1; p", `(lines[0]).start.x = (-1);p;`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`struct { x }`, "expected next token to be , got { instead"},
+		{`struct Point { x y }`, "expected next token to be ,, got y instead"},
+		{`struct Point { x, x }`, "duplicate field x in struct Point"},
+		{`Point{x: 1, x: 2}`, "duplicate field x in Point{...}"},
+		{`Point{x 1}`, "expected next token to be :, got 1 instead"},
+		{`x = 1`, "cannot assign to x, only to the field of a struct"},
+		{`p[0] = 1`, "cannot assign to (p[0]), only to the field of a struct"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors) == 0 || p.Errors[0] != tt.expectedError {
+			t.Errorf("%s: expected the parse error %q, got=%q", tt.input, tt.expectedError, p.Errors)
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, `{};`},
+		{`{"one": 1, 2: "two", true: [3]}`, `{"one": 1, 2: "two", true: [3]};`},
+		{`{key: 1 + 2, "k" + "v": f(3)}["k"]`, `({key: (1 + 2), ("k" + "v"): f(3)}["k"]);`},
+		{"{\n  \"a\": {\"b\": 1}\n}", `{"a": {"b": 1}};`},
+		{`let h = {"a": 1}; h == {"a": 1}`, `let h = {"a": 1};(h == {"a": 1});`},
+		{`match (h) { {"a": a} => ({"b": a}), _ => {} }`, `match (h) {{"a": a} => ({"b": a}), _ => {}};`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`{"a" 1}`, "expected next token to be :, got 1 instead"},
+		{`{"a": 1 "b": 2}`, "expected next token to be ,, got b instead"},
+		{`{"a": }`, "No prefix parse function found for } token"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors) == 0 || p.Errors[0] != tt.expectedError {
+			t.Errorf("%s: expected the parse error %q, got=%q", tt.input, tt.expectedError, p.Errors)
+		}
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (x) { 1 => "one", _ => "other" }`, `match (x) {1 => "one", _ => "other"};`},
+		{"match (f(x)) {\n  -1 => a + b,\n  true => { let y = 1; y }\n  [] => 0,\n}", `match (f(x)) {-1 => (a + b), true => {let y = 1;y;}, [] => 0};`},
+		{`match (v) { [a, [b, _]] => a }`, `match (v) {[a, [b, _]] => a};`},
+		{`match (p) { Point{x: 0, y: y} => y, {"k": [v], "n": 1} => v }`, `match (p) {Point{x: 0, y: y} => y, {"k": [v], "n": 1} => v};`},
+		{`match (v) { _ => {} }`, `match (v) {_ => {}};`},
+		{`1 + match (v) { x => x } * 2`, `(1 + (match (v) {x => x} * 2));`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`match (v) {}`, "expected an arm in match"},
+		{`match v { _ => 1 }`, "expected next token to be (, got v instead"},
+		{`match (v) { 1 => 2 3 => 4 }`, "expected next token to be ,, got 3 instead"},
+		{`match (v) { 1 + 2 => 3 }`, "expected next token to be =>, got + instead"},
+		{`match (v) { f(x) => 3 }`, "expected next token to be =>, got ( instead"},
+		{`match (v) { (1) => 3 }`, "expected a pattern, got ( instead"},
+		{`match (v) { -x => 3 }`, "expected next token to be , got x instead"},
+		{`match (v) { [a, a] => a }`, "a is bound more than once in the pattern"},
+		{`match (v) { P{x: a, x: b} => a }`, "duplicate field x in P{...}"},
+		{`match (v) { {"k": a, "k": b} => a }`, `duplicate key "k" in the hash pattern`},
+		{`match (v) { {k: a} => a }`, "expected next token to be , got k instead"},
+		{`match (v) { _ => 1`, "expected } to close the match, got end of input instead"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors) == 0 || p.Errors[0] != tt.expectedError {
+			t.Errorf("%s: expected the parse error %q, got=%q", tt.input, tt.expectedError, p.Errors)
+		}
+	}
+}
+
+func TestUnterminatedBlock(t *testing.T) {
+	p := New(lexer.New("func(x) { x + 1"))
+	p.ParseProgram()
+
+	if len(p.Errors) == 0 {
+		t.Fatalf("expected a parse error for an unterminated block")
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	p := New(lexer.New("let s = \"abc\nlet t = 1;\n"))
+	p.ParseProgram()
+
+	expected := []string{"unterminated string, it has no closing quote"}
+	if len(p.Errors) != len(expected) || p.Errors[0] != expected[0] {
+		t.Fatalf("wrong parse errors. expected=%q, got=%q", expected, p.Errors)
+	}
+	if p.ErrorTokens[0].Line != 1 || p.ErrorTokens[0].Column != 9 {
+		t.Errorf("wrong position of the error. expected=1:9, got=%d:%d", p.ErrorTokens[0].Line, p.ErrorTokens[0].Column)
+	}
+}
+
+func TestErrorTokens(t *testing.T) {
+	p := New(lexer.New("let x = 1\nlet = 5"))
+	p.ParseProgram()
+
+	if len(p.ErrorTokens) != len(p.Errors) {
+		t.Fatalf("wrong number of error tokens. expected=%d, got=%d", len(p.Errors), len(p.ErrorTokens))
+	}
+
+	if p.ErrorTokens[0].Line != 2 || p.ErrorTokens[0].Column != 5 {
+		t.Errorf("wrong position of the error %q. expected=2:5, got=%d:%d", p.Errors[0], p.ErrorTokens[0].Line, p.ErrorTokens[0].Column)
+	}
+}
+
+// fuzzSeeds returns the programs the fuzz targets start from, the programs of the conformance suite and a few inputs
+// that used to break the parser.
+func fuzzSeeds(f *testing.F) []string {
+	seeds := []string{
+		"let add = func(x, y) { x + y; }; add(1, 2)",
+		"if (a < b) { a } else { -b }",
+		"import \"lib.yz\" as lib\nexport let x = try { throw lib.y[0] } catch (e) { e } finally { 2 }",
+		"func(x) { x + 1",
+		`"unterminated`,
+		"let = 5; ) ( ] [",
+		"select { case v = receive(ch) { v } case send(out, spawn f(1)) {} default { 0 } }",
+		"struct P { x, y }; let p = P{y: 2, x: 1}; p.x = P(1, 2); p == p",
+		`match (v) { -1 => 0, [a, P{x: b}] => { a + b } {"k": _} => "k", _ => match (v) { x => x } }`,
+	}
+
+	paths, err := filepath.Glob(filepath.Join("..", "conformance", "testdata", "*.yz"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		seeds = append(seeds, string(src))
+	}
+	return seeds
+}
+
+// FuzzParseProgram checks that the parser never panics, and that the source code String outputs for a program is
+// parsed again to the same program.
+func FuzzParseProgram(f *testing.F) {
+	for _, seed := range fuzzSeeds(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors) != 0 {
+			return
+		}
+
+		src := program.String()
+		reparser := New(lexer.New(src))
+		reparsed := reparser.ParseProgram()
+		if len(reparser.Errors) != 0 {
+			t.Fatalf("the output of String for %q does not parse: %q\n%s", input, src, strings.Join(reparser.Errors, "\n"))
+		}
+		if reparsed.String() != src {
+			t.Fatalf("the output of String for %q is parsed to another program.\nfirst:  %q\nsecond: %q", input, src, reparsed.String())
+		}
+	})
+}
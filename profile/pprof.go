@@ -0,0 +1,164 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+/* The pprof format
+- A pprof profile is a gzipped protocol buffer message, described by
+	https://github.com/google/pprof/blob/main/proto/profile.proto, which `go tool pprof` reads.
+- The few messages and fields it needs are encoded by hand, so the profile package has no dependencies.
+- Every sample has 2 values, the number of statements and the nanoseconds charged to its call stack.
+- Every line of a function is a location, a sample lists the locations of its call stack with the innermost first.
+*/
+
+// The numbers of the fields of the messages of profile.proto that are written.
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+)
+
+// protoBuffer is a type for representing an encoded protocol buffer message.
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protoBuffer) key(field, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) int64Field(field int, x int64) {
+	b.key(field, 0)
+	b.varint(uint64(x))
+}
+
+func (b *protoBuffer) bytesField(field int, data []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) packedField(field int, xs []int64) {
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(uint64(x))
+	}
+	b.bytesField(field, packed.data)
+}
+
+// stringTable is a type for representing the strings of a profile, which the messages refer to by their index.
+type stringTable struct {
+	strings []string
+	indexes map[string]int64
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{strings: []string{""}, indexes: map[string]int64{"": 0}}
+}
+
+func (t *stringTable) index(s string) int64 {
+	if idx, ok := t.indexes[s]; ok {
+		return idx
+	}
+	t.indexes[s] = int64(len(t.strings))
+	t.strings = append(t.strings, s)
+	return t.indexes[s]
+}
+
+func valueType(strs *stringTable, typ, unit string) []byte {
+	var b protoBuffer
+	b.int64Field(valueTypeType, strs.index(typ))
+	b.int64Field(valueTypeUnit, strs.index(unit))
+	return b.data
+}
+
+// WritePprof writes the profile in the gzipped protocol buffer format of pprof.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	var b protoBuffer
+	strs := newStringTable()
+	functionIDs := make(map[string]int64)
+	locationIDs := make(map[Location]int64)
+
+	b.bytesField(profileSampleType, valueType(strs, "statements", "count"))
+	b.bytesField(profileSampleType, valueType(strs, "time", "nanoseconds"))
+
+	var functions, locations protoBuffer
+	for _, s := range p.Samples() {
+		ids := make([]int64, 0, len(s.Stack))
+		for idx := len(s.Stack) - 1; idx >= 0; idx-- {
+			loc := s.Stack[idx]
+
+			if _, ok := functionIDs[loc.Function]; !ok {
+				functionIDs[loc.Function] = int64(len(functionIDs) + 1)
+				var function protoBuffer
+				function.int64Field(functionID, functionIDs[loc.Function])
+				function.int64Field(functionName, strs.index(loc.Function))
+				function.int64Field(functionSystemName, strs.index(loc.Function))
+				functions.bytesField(profileFunction, function.data)
+			}
+
+			if _, ok := locationIDs[loc]; !ok {
+				locationIDs[loc] = int64(len(locationIDs) + 1)
+				var line, location protoBuffer
+				line.int64Field(lineFunctionID, functionIDs[loc.Function])
+				line.int64Field(lineLine, int64(loc.Line))
+				location.int64Field(locationID, locationIDs[loc])
+				location.bytesField(locationLine, line.data)
+				locations.bytesField(profileLocation, location.data)
+			}
+
+			ids = append(ids, locationIDs[loc])
+		}
+
+		var sample protoBuffer
+		sample.packedField(sampleLocationID, ids)
+		sample.packedField(sampleValue, []int64{int64(s.Hits), s.Time.Nanoseconds()})
+		b.bytesField(profileSample, sample.data)
+	}
+
+	b.data = append(b.data, locations.data...)
+	b.data = append(b.data, functions.data...)
+	b.int64Field(profileDurationNanos, p.total.Nanoseconds())
+	b.bytesField(profilePeriodType, valueType(strs, "time", "nanoseconds"))
+	b.int64Field(profilePeriod, 1)
+	for _, s := range strs.strings {
+		b.bytesField(profileStringTable, []byte(s))
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(b.data); err != nil {
+		return err
+	}
+	return gz.Close()
+}
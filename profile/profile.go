@@ -0,0 +1,263 @@
+// Package profile records where yeezy programs spend their time, the way `yeezy run -profile` does.
+//
+// A Profiler is the evaluator.Hook of the interpreter that runs the program. Before every statement it charges the
+// time since the previous statement to the call stack the previous statement was evaluated in, so the time of a
+// statement includes the built-in functions it called, and the time of a function includes the functions it called.
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/object"
+)
+
+// Location is a type for representing a line of a function, or of a program.
+type Location struct {
+	Function string
+	Line     int
+}
+
+func (loc Location) String() string { return fmt.Sprintf("%s:%d", loc.Function, loc.Line) }
+
+// Sample is a type for representing the time spent in the statements evaluated with one call stack.
+type Sample struct {
+	Stack []Location // the outermost call is first, the line of the statement is last
+	Hits  int        // number of statements evaluated with this call stack
+	Time  time.Duration
+}
+
+// FunctionStats is a type for representing the time spent in the calls of a function.
+type FunctionStats struct {
+	Name       string
+	Calls      int
+	Flat       time.Duration // time spent in the statements of the function itself
+	Cumulative time.Duration // time spent in the function and in the functions it called
+}
+
+// LineStats is a type for representing the time spent in the statements of a line.
+type LineStats struct {
+	Location
+	Hits int // number of statements evaluated on the line
+	Time time.Duration
+}
+
+// Profiler is a type for representing the profiling of the programs one interpreter evaluates.
+type Profiler struct {
+	interpreter *evaluator.Interpreter
+	now         func() time.Time // the clock, which tests replace
+
+	samples    map[string]*Sample // by the locations of their stack
+	calls      map[string]int     // by the name of the function
+	maxFrameID int                // the frames with a greater ID are calls that were not counted yet
+	current    *Sample            // the sample of the statement that is being evaluated
+	last       time.Time          // when the statement that is being evaluated started
+	total      time.Duration
+}
+
+// New returns a profiler that becomes the hook of interpreter.
+func New(interpreter *evaluator.Interpreter) *Profiler {
+	p := &Profiler{
+		interpreter: interpreter,
+		now:         time.Now,
+		samples:     make(map[string]*Sample),
+		calls:       make(map[string]int),
+	}
+	interpreter.Hook = p
+	return p
+}
+
+// BeforeNode counts the calls that started since the previous statement, and charges the time since it.
+func (p *Profiler) BeforeNode(node ast.Node, env *object.Environment) {
+	if _, ok := node.(ast.StatementNode); !ok {
+		return
+	}
+
+	stack := p.interpreter.CallStack()
+	// Frames get increasing IDs, so the calls that were not counted yet are the innermost ones.
+	for idx := 0; idx < len(stack) && stack[idx].ID > p.maxFrameID; idx++ {
+		p.calls[stack[idx].Name]++
+	}
+	if len(stack) > 0 && stack[0].ID > p.maxFrameID {
+		p.maxFrameID = stack[0].ID
+	}
+
+	// A block starts a call or a branch, the statements in it are charged for.
+	if _, isBlock := node.(*ast.BlockStatementNode); isBlock {
+		return
+	}
+
+	p.Stop()
+	p.current = p.sample(stack)
+	p.current.Hits++
+	p.last = p.now()
+}
+
+// Stop charges the time since the last statement to it, it is called once the program was evaluated.
+func (p *Profiler) Stop() {
+	if p.current == nil {
+		return
+	}
+	elapsed := p.now().Sub(p.last)
+	p.current.Time += elapsed
+	p.total += elapsed
+	p.current = nil
+}
+
+// sample returns the sample of a call stack, the innermost call is first in stack.
+func (p *Profiler) sample(stack []evaluator.StackFrame) *Sample {
+	locations := make([]Location, 0, len(stack))
+	keys := make([]string, 0, len(stack))
+	for idx := len(stack) - 1; idx >= 0; idx-- {
+		loc := Location{Function: stack[idx].Name, Line: stack[idx].Line}
+		locations = append(locations, loc)
+		keys = append(keys, loc.String())
+	}
+
+	key := strings.Join(keys, ";")
+	s, ok := p.samples[key]
+	if !ok {
+		s = &Sample{Stack: locations}
+		p.samples[key] = s
+	}
+	return s
+}
+
+// Total returns the time charged to all the statements.
+func (p *Profiler) Total() time.Duration { return p.total }
+
+// Samples returns the samples, sorted by their call stacks.
+func (p *Profiler) Samples() []*Sample {
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	samples := make([]*Sample, 0, len(keys))
+	for _, key := range keys {
+		samples = append(samples, p.samples[key])
+	}
+	return samples
+}
+
+// Functions returns the stats of the functions that were called, the one with the most cumulative time first.
+func (p *Profiler) Functions() []FunctionStats {
+	stats := make(map[string]*FunctionStats)
+	get := func(name string) *FunctionStats {
+		if _, ok := stats[name]; !ok {
+			stats[name] = &FunctionStats{Name: name, Calls: p.calls[name]}
+		}
+		return stats[name]
+	}
+
+	for name := range p.calls {
+		get(name)
+	}
+	for _, s := range p.samples {
+		get(s.Stack[len(s.Stack)-1].Function).Flat += s.Time
+
+		// A recursive function is charged once for the time of a statement.
+		charged := make(map[string]bool)
+		for _, loc := range s.Stack {
+			if !charged[loc.Function] {
+				charged[loc.Function] = true
+				get(loc.Function).Cumulative += s.Time
+			}
+		}
+	}
+
+	functions := make([]FunctionStats, 0, len(stats))
+	for _, fs := range stats {
+		functions = append(functions, *fs)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].Cumulative != functions[j].Cumulative {
+			return functions[i].Cumulative > functions[j].Cumulative
+		}
+		return functions[i].Name < functions[j].Name
+	})
+	return functions
+}
+
+// Lines returns the stats of the lines that statements were evaluated on, the one with the most time first.
+func (p *Profiler) Lines() []LineStats {
+	stats := make(map[Location]*LineStats)
+	for _, s := range p.samples {
+		loc := s.Stack[len(s.Stack)-1]
+		if _, ok := stats[loc]; !ok {
+			stats[loc] = &LineStats{Location: loc}
+		}
+		stats[loc].Hits += s.Hits
+		stats[loc].Time += s.Time
+	}
+
+	lines := make([]LineStats, 0, len(stats))
+	for _, ls := range stats {
+		lines = append(lines, *ls)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Time != lines[j].Time {
+			return lines[i].Time > lines[j].Time
+		}
+		if lines[i].Function != lines[j].Function {
+			return lines[i].Function < lines[j].Function
+		}
+		return lines[i].Line < lines[j].Line
+	})
+	return lines
+}
+
+// WriteFolded writes the profile as folded stacks, one `outer;inner nanoseconds` line per call stack, which flame
+// graph tools like flamegraph.pl and speedscope read.
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	folded := make(map[string]time.Duration)
+	keys := []string{}
+	for _, s := range p.Samples() {
+		names := make([]string, 0, len(s.Stack))
+		for _, loc := range s.Stack {
+			names = append(names, foldedName(loc.Function))
+		}
+		key := strings.Join(names, ";")
+		if _, ok := folded[key]; !ok {
+			keys = append(keys, key)
+		}
+		folded[key] += s.Time
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s %d\n", key, folded[key].Nanoseconds()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// foldedName replaces the characters that separate frames and counts in folded stacks.
+func foldedName(name string) string {
+	return strings.NewReplacer(";", "_", " ", "_").Replace(name)
+}
+
+// WriteText writes the stats of the functions and of the lines as tables.
+func (p *Profiler) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "total time: %s\n\n", p.total)
+
+	fmt.Fprintf(w, "%-24s %8s %14s %14s\n", "function", "calls", "flat", "cumulative")
+	for _, fs := range p.Functions() {
+		fmt.Fprintf(w, "%-24s %8d %14s %14s\n", fs.Name, fs.Calls, fs.Flat, fs.Cumulative)
+	}
+
+	fmt.Fprintf(w, "\n%-24s %8s %14s\n", "line", "hits", "time")
+	for _, ls := range p.Lines() {
+		if _, err := fmt.Fprintf(w, "%-24s %8d %14s\n", ls.Location, ls.Hits, ls.Time); err != nil {
+			return err
+		}
+	}
+	return nil
+}
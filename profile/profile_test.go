@@ -0,0 +1,112 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+)
+
+const fibonacci = `let fib = func(n) {
+  if (n < 2) {
+    return n
+  }
+  fib(n - 1) + fib(n - 2)
+}
+fib(3)`
+
+// newTestProfile profiles a program with a clock that moves 1ms every time it is read, so every statement takes 1ms.
+func newTestProfile(t *testing.T, input string) *Profiler {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		t.Fatalf("parse errors in %q: %v", input, p.Errors)
+	}
+
+	interpreter := evaluator.New()
+	profiler := New(interpreter)
+	clock := time.Unix(0, 0)
+	profiler.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+
+	if result := interpreter.Eval(program, object.NewEnvironment()); result.Inspect() != "2" {
+		t.Fatalf("wrong result. expected=2, got=%s", result.Inspect())
+	}
+	profiler.Stop()
+	return profiler
+}
+
+func TestFunctionsAndLines(t *testing.T) {
+	profiler := newTestProfile(t, fibonacci)
+
+	// fib(3) calls fib(2) and fib(1), fib(2) calls fib(1) and fib(0): 5 calls of 2 statements each, 3 of which return on line 3.
+	if profiler.Total() != 12*time.Millisecond {
+		t.Errorf("wrong total time. expected=12ms, got=%s", profiler.Total())
+	}
+
+	functions := []string{}
+	for _, fs := range profiler.Functions() {
+		functions = append(functions, fmt.Sprintf("%s %d %s %s", fs.Name, fs.Calls, fs.Flat, fs.Cumulative))
+	}
+	expected := "<program> 1 2ms 12ms, fib 5 10ms 10ms"
+	if strings.Join(functions, ", ") != expected {
+		t.Errorf("wrong functions.\nexpected=%q\ngot=     %q", expected, strings.Join(functions, ", "))
+	}
+
+	lines := []string{}
+	for _, ls := range profiler.Lines() {
+		lines = append(lines, fmt.Sprintf("%s %d %s", ls.Location, ls.Hits, ls.Time))
+	}
+	expected = "fib:2 5 5ms, fib:3 3 3ms, fib:5 2 2ms, <program>:1 1 1ms, <program>:7 1 1ms"
+	if strings.Join(lines, ", ") != expected {
+		t.Errorf("wrong lines.\nexpected=%q\ngot=     %q", expected, strings.Join(lines, ", "))
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	var out bytes.Buffer
+	if err := newTestProfile(t, fibonacci).WriteFolded(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<program> 2000000
+<program>;fib 2000000
+<program>;fib;fib 4000000
+<program>;fib;fib;fib 4000000
+`
+	if out.String() != expected {
+		t.Errorf("wrong folded stacks.\nexpected=%q\ngot=     %q", expected, out.String())
+	}
+}
+
+func TestWritePprof(t *testing.T) {
+	var out bytes.Buffer
+	if err := newTestProfile(t, fibonacci).WritePprof(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("the profile is not gzipped: %s", err)
+	}
+	data, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, str := range []string{"statements", "nanoseconds", "<program>", "fib"} {
+		if !bytes.Contains(data, []byte(str)) {
+			t.Errorf("the string table of the profile is missing %q", str)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/evaluator"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+	"github.com/shksa/yeezy/token"
+)
+
+// CONTPROMPT is the prompt message for the lines that continue an incomplete input in the repl.
+const CONTPROMPT = ".. "
+
+// continuingTokens are the tokens that cannot end a statement, so an input ending with one of them continues on the next line.
+var continuingTokens = map[string]bool{
+	token.ASSIGN.Type:   true,
+	token.PLUS.Type:     true,
+	token.MINUS.Type:    true,
+	token.BANG.Type:     true,
+	token.ASTERISK.Type: true,
+	token.SLASH.Type:    true,
+	token.LT.Type:       true,
+	token.GT.Type:       true,
+	token.EQ.Type:       true,
+	token.NOTEQ.Type:    true,
+	token.COMMA.Type:    true,
+	token.COLON.Type:    true,
+	token.ARROW.Type:    true,
+	token.DOT.Type:      true,
+	token.FUNCTION.Type: true,
+	token.LET.Type:      true,
+	token.CONST.Type:    true,
+	token.IF.Type:       true,
+	token.MATCH.Type:    true,
+	token.ELSE.Type:     true,
+	token.RETURN.Type:   true,
+	token.IMPORT.Type:   true,
+	token.AS.Type:       true,
+	token.EXPORT.Type:   true,
+	token.TRY.Type:      true,
+	token.CATCH.Type:    true,
+	token.FINALLY.Type:  true,
+	token.THROW.Type:    true,
+}
+
+// isIncompleteInput tells whether the input read by the repl so far needs more lines to be a complete statement.
+// An input is incomplete when it has an unterminated string, more opening than closing brackets, or ends with an operator.
+func isIncompleteInput(input string) bool {
+	depth := 0
+	lastToken := token.EOF
+	l := lexer.New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF.Type; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN.Type, token.LBRACE.Type, token.LBRACKET.Type:
+			depth++
+		case token.RPAREN.Type, token.RBRACE.Type, token.RBRACKET.Type:
+			depth--
+		}
+		lastToken = tok
+	}
+
+	return depth > 0 || continuingTokens[lastToken.Type] || lastToken.Type == token.UNTERMINATED.Type
+}
+
+// session is a type for representing the state the repl keeps for its whole lifetime.
+type session struct {
+	interpreter *evaluator.Interpreter
+	env         *object.Environment
+	out         io.Writer
+}
+
+func newSession(out io.Writer) *session {
+	return &session{interpreter: newInterpreter(nil), env: object.NewEnvironment(), out: out}
+}
+
+// eval evaluates the input in the session's environment and prints the result.
+func (s *session) eval(input string) {
+	program, ok := s.parse(input)
+	if !ok {
+		return
+	}
+
+	s.printResult(s.interpreter.Eval(program, s.env))
+}
+
+func (s *session) parse(input string) (*ast.Program, bool) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if len(p.Errors) != 0 {
+		printParseErrors(s.out, p.Errors)
+		return nil, false
+	}
+
+	return program, true
+}
+
+func (s *session) printResult(evaluated object.Object) {
+	if evaluated != nil {
+		io.WriteString(s.out, evaluated.Inspect())
+		io.WriteString(s.out, "\n")
+	}
+}
+
+// replCommands is the list of the repl's colon commands, with their usage and what they do.
+var replCommands = []struct {
+	usage string
+	help  string
+}{
+	{":env", "list the bindings of the session"},
+	{":tokens <src>", "print the tokens of the source code"},
+	{":ast <src>", "print the syntax tree of the source code"},
+	{":type <expr>", "evaluate the expression and print the type of its value"},
+	{":load <file.yz>", "evaluate a file in the session"},
+	{":reset", "forget all the bindings and imported modules of the session"},
+	{":help", "print this list of commands"},
+	{":quit", "leave the repl, same as exit"},
+}
+
+// isCommand tells whether a line read by the repl is a colon command instead of yeezy code.
+func isCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), ":")
+}
+
+// runCommand runs a colon command of the repl, and tells whether the repl should quit.
+func (s *session) runCommand(line string) bool {
+	command := strings.TrimSpace(line)
+	arg := ""
+	if idx := strings.IndexAny(command, " \t"); idx != -1 {
+		command, arg = command[:idx], strings.TrimSpace(command[idx:])
+	}
+
+	switch command {
+	case ":env":
+		for _, name := range s.env.Names() {
+			value, _ := s.env.Get(name)
+			fmt.Fprintf(s.out, "%s = %s\n", name, value.Inspect())
+		}
+
+	case ":tokens":
+		l := lexer.New(arg)
+		for tok := l.NextToken(); tok.Type != token.EOF.Type; tok = l.NextToken() {
+			fmt.Fprintf(s.out, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+		}
+
+	case ":ast":
+		if program, ok := s.parse(arg); ok {
+			io.WriteString(s.out, ast.Dump(program))
+		}
+
+	case ":type":
+		if program, ok := s.parse(arg); ok {
+			if evaluated := s.interpreter.Eval(program, s.env); evaluated != nil {
+				fmt.Fprintln(s.out, evaluated.Type())
+			}
+		}
+
+	case ":load":
+		fileContent, err := ioutil.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintln(s.out, err)
+			break
+		}
+		if program, ok := s.parse(string(fileContent)); ok {
+			s.printResult(s.interpreter.EvalFile(arg, program, s.env))
+		}
+
+	case ":reset":
+		s.interpreter = newInterpreter(nil)
+		s.env = object.NewEnvironment()
+
+	case ":help":
+		for _, cmd := range replCommands {
+			fmt.Fprintf(s.out, "%-18s %s\n", cmd.usage, cmd.help)
+		}
+
+	case ":quit":
+		return true
+
+	default:
+		fmt.Fprintf(s.out, "unknown command %s, type :help for the list of commands\n", command)
+	}
+
+	return false
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsIncompleteInput(t *testing.T) {
+	tests := []struct {
+		input      string
+		incomplete bool
+	}{
+		{"let x = 5", false},
+		{"let x =", true},
+		{"5 +", true},
+		{"add(1,", true},
+		{"let greet = func(name) {", true},
+		{"let greet = func(name) {\n  \"hi \" + name\n}", false},
+		{`"unterminated`, true},
+		{`"a" + "b"`, false},
+		{`let a = 1 // it"s`, false},
+		{"let s = \"multi\nline", true},
+		{"let s = \"multi\nline\"", false},
+		{`""`, false},
+		{`"`, true},
+		{`// "`, false},
+		{"[1, 2", true},
+		{"if (x) { 1 } else", true},
+		{"}", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isIncompleteInput(tt.input); got != tt.incomplete {
+			t.Errorf("isIncompleteInput(%q) = %t, want %t", tt.input, got, tt.incomplete)
+		}
+	}
+}
+
+func TestREPLMultiLineInput(t *testing.T) {
+	input := `let makeGreeter = func(greet) {
+  func(name) {
+    greet + "! " + name
+  }
+}
+let hello = makeGreeter("hello")
+hello("joe rogan")
+`
+	var out bytes.Buffer
+	start(strings.NewReader(input), &out)
+
+	if !strings.Contains(out.String(), "hello! joe rogan\n") {
+		t.Errorf("multi-line function literal was not evaluated. got=%q", out.String())
+	}
+
+	if strings.Count(out.String(), CONTPROMPT) != 4 {
+		t.Errorf("expected 4 continuation prompts. got=%q", out.String())
+	}
+}
+
+func TestREPLCommands(t *testing.T) {
+	dir := t.TempDir()
+	libFile := filepath.Join(dir, "lib.yz")
+	if err := ioutil.WriteFile(libFile, []byte(`let loaded = 7`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 5\nlet s = \"hi\"\n:env", "s = hi\nx = 5\n"},
+		{":tokens let x", "1:1\tLET\t\"let\"\n1:5\tIDENTIFIER\t\"x\"\n"},
+		{":ast x", "Program\n  ExpressionStatementNode 1:1 \"x\"\n    Expression: IdentifierNode 1:1 \"x\"\n"},
+		{":type \"abc\".upper()", "STRING\n"},
+		{":load " + libFile + "\nloaded", "7\n"},
+		{"let x = 5\n:reset\n:env", ""},
+		{":nope", "unknown command :nope, type :help for the list of commands\n"},
+		{":quit\n5", ""},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		start(strings.NewReader(tt.input), &out)
+
+		got := strings.Replace(out.String(), PROMPT, "", -1)
+		if got != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s := newSession(&bytes.Buffer{})
+	s.eval("let length = 5; let ret = 1")
+
+	tests := []struct {
+		word        string
+		atLineStart bool
+		expected    []string
+	}{
+		{"le", true, []string{"let", "len", "length"}},
+		{"ret", false, []string{"return", "ret"}},
+		{"isE", false, []string{"isError"}},
+		{":l", true, []string{":load "}},
+		{":l", false, []string{}},
+		{"zzz", true, []string{}},
+	}
+
+	for _, tt := range tests {
+		got := s.complete(tt.word, tt.atLineStart)
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("complete(%q) = %v, want %v", tt.word, got, tt.expected)
+		}
+	}
+
+	head, word := splitWordBeforeCursor("let x = len")
+	if head != "let x = " || word != "len" {
+		t.Errorf("splitWordBeforeCursor is wrong. got head=%q word=%q", head, word)
+	}
+}
+
+func TestEditingLineReader(t *testing.T) {
+	dir := t.TempDir()
+	historyPath := filepath.Join(dir, HISTORYFILE)
+	if err := ioutil.WriteFile(historyPath, []byte("let a = 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys := filepath.Join(dir, "keys")
+	if err := ioutil.WriteFile(keys, []byte("isE\t(1)\r\x1b[A\r\x1b[A\x1b[A\r  \rab\x1b[Dc\x7f\x7fX\x1b[3~\r\x03\x04"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	in, err := os.Open(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	// Another session that is open at the same time adds its lines to the history file too.
+	other := newEditingLineReader(newSession(&bytes.Buffer{}), in, &bytes.Buffer{}, historyPath)
+	r := newEditingLineReader(newSession(&bytes.Buffer{}), in, &bytes.Buffer{}, historyPath)
+
+	for _, expected := range []string{"isError(1)", "isError(1)", "let a = 1", "  ", "X"} {
+		if line, err := r.Prompt(PROMPT); err != nil || line != expected {
+			t.Errorf("wrong line. expected=%q, got=%q (err=%v)", expected, line, err)
+		}
+	}
+	if _, err := r.Prompt(PROMPT); err != errPromptAborted {
+		t.Errorf("expected Ctrl-C to abort the prompt, got=%v", err)
+	}
+	if _, err := r.Prompt(PROMPT); err != io.EOF {
+		t.Errorf("expected Ctrl-D to end the input, got=%v", err)
+	}
+	other.historyFile.WriteString("other\n")
+	r.Close()
+	other.Close()
+
+	history, _ := ioutil.ReadFile(historyPath)
+	expected := "let a = 1\nisError(1)\nlet a = 1\nX\nother\n"
+	if string(history) != expected {
+		t.Errorf("wrong history file.\nexpected=%q\ngot=     %q", expected, history)
+	}
+}
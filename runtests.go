@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shksa/yeezy/ast"
+	"github.com/shksa/yeezy/cover"
+	"github.com/shksa/yeezy/lexer"
+	"github.com/shksa/yeezy/object"
+	"github.com/shksa/yeezy/parser"
+)
+
+/* yeezy test
+- Tests are the files whose names end in _test.yz, in the files and directories given to yeezy test, "." by default.
+- Every test file is evaluated by its own interpreter, then every function it binds at its top level with a name that
+	starts with test_ is called without arguments, in the order they are declared. A test passes if it returns without
+	an error, the assert, assertEqual and assertThrows built-ins raise the errors that fail it.
+- The tests of a file share the environment of the file. An error while the file itself is evaluated fails the file
+	without running its tests.
+- With -cover, the statements and the if/else branches of the other .yz files that the tests evaluate, or that are in
+	the given directories, are counted, and the percentage of them that the tests evaluated is printed for every file.
+*/
+
+// testCounts is a type for representing the number of tests that passed and failed.
+type testCounts struct {
+	passed, failed int
+}
+
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.yz")
+}
+
+func testCommand(args []string, out, errOut io.Writer) int {
+	fs := newFlagSet("test", errOut)
+	addSearchPathFlag(fs)
+	verbose := fs.Bool("v", false, "print every test, not only the failed ones")
+	coverMode := fs.Bool("cover", false, "print how much of the statements and branches of each file the tests evaluated")
+	coverProfile := fs.String("coverprofile", "", "write a coverage report to this file, it implies -cover")
+	coverFormat := fs.String("coverformat", "lcov", "format of the coverage report: lcov or html")
+	if err := fs.Parse(args); err != nil {
+		return exitUsageError
+	}
+
+	if *coverFormat != "lcov" && *coverFormat != "html" {
+		fmt.Fprintf(errOut, "unknown coverage format %q, it is lcov or html\n", *coverFormat)
+		return exitUsageError
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	filePaths, err := sourceFiles(paths)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitUsageError
+	}
+
+	var coverage *cover.Coverage
+	if *coverMode || *coverProfile != "" {
+		coverage = cover.New()
+		coverage.Skip = isTestFile
+	}
+
+	testFiles := []string{}
+	for _, filePath := range filePaths {
+		if isTestFile(filePath) {
+			testFiles = append(testFiles, filePath)
+		} else if coverage != nil {
+			addCoveredFile(coverage, filePath)
+		}
+	}
+
+	if len(testFiles) == 0 {
+		fmt.Fprintln(out, "no test files")
+		return exitOK
+	}
+
+	total := testCounts{}
+	for _, testFile := range testFiles {
+		counts := runTestFile(testFile, coverage, *verbose, out)
+		total.passed += counts.passed
+		total.failed += counts.failed
+	}
+
+	if coverage != nil {
+		printCoverage(coverage, out)
+		if *coverProfile != "" {
+			if err := writeCoverage(coverage, *coverProfile, *coverFormat); err != nil {
+				fmt.Fprintln(errOut, err)
+				return exitUsageError
+			}
+		}
+	}
+
+	if total.failed > 0 {
+		fmt.Fprintf(out, "FAIL: %d failed, %d passed\n", total.failed, total.passed)
+		return exitTestsFailed
+	}
+	fmt.Fprintf(out, "ok: %d passed\n", total.passed)
+	return exitOK
+}
+
+// addCoveredFile registers a file the tests may not import, so that its coverage is reported too.
+func addCoveredFile(coverage *cover.Coverage, filePath string) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return
+	}
+	src, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return
+	}
+
+	// A file with parse errors can not be covered, the tests that import it fail.
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors) == 0 {
+		coverage.AddFile(absPath, program)
+	}
+}
+
+// runTestFile evaluates a test file and runs its tests, prints the failed ones and a line for the file, and returns
+// the number of tests that passed and failed. A file that can not be evaluated counts as 1 failed test.
+func runTestFile(filePath string, coverage *cover.Coverage, verbose bool, out io.Writer) testCounts {
+	counts := testCounts{}
+	failFile := func(format string, a ...interface{}) testCounts {
+		fmt.Fprintf(out, "FAIL %s\n\t"+format+"\n", append([]interface{}{filePath}, a...)...)
+		return testCounts{failed: 1}
+	}
+
+	src, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return failFile("%s", err)
+	}
+
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors) != 0 {
+		return failFile("parse errors:\n\t\t%s", strings.Join(p.Errors, "\n\t\t"))
+	}
+
+	interpreter := newInterpreter(nil)
+	if coverage != nil {
+		interpreter.Hook = coverage
+	}
+
+	env := object.NewEnvironment()
+	if errObj, ok := interpreter.EvalFile(filePath, program, env).(*object.Error); ok {
+		return failFile("%s", errorWithStack(errObj, "\t\t"))
+	}
+
+	for _, name := range testFunctions(program) {
+		fn, _ := env.Get(name)
+		if _, ok := fn.(*object.Function); !ok {
+			continue
+		}
+		if errObj, ok := interpreter.Call(fn).(*object.Error); ok {
+			fmt.Fprintf(out, "--- FAIL: %s\n\t%s\n", name, errorWithStack(errObj, "\t\t"))
+			counts.failed++
+			continue
+		}
+		if verbose {
+			fmt.Fprintf(out, "--- ok: %s\n", name)
+		}
+		counts.passed++
+	}
+
+	if counts.failed > 0 {
+		fmt.Fprintf(out, "FAIL %s\t%d failed, %d passed\n", filePath, counts.failed, counts.passed)
+	} else {
+		fmt.Fprintf(out, "ok   %s\t%d passed\n", filePath, counts.passed)
+	}
+	return counts
+}
+
+// testFunctions returns the names the let statements at the top level of a test file bind that start with test_, in
+// order. The ones that are not bound to functions are not tests.
+func testFunctions(program *ast.Program) []string {
+	names := []string{}
+	for _, stmt := range program.Statements {
+		if export, ok := stmt.(*ast.ExportStatementNode); ok {
+			stmt = export.Statement
+		}
+		if let, ok := stmt.(*ast.LetStatementNode); ok && strings.HasPrefix(let.Iden.Name, "test_") {
+			names = append(names, let.Iden.Name)
+		}
+	}
+	return names
+}
+
+// errorWithStack returns an error message followed by its stack, one frame per line with indent before it.
+func errorWithStack(errObj *object.Error, indent string) string {
+	lines := []string{errObj.Inspect()}
+	for _, frame := range errObj.Stack {
+		lines = append(lines, indent+frame)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// printCoverage prints the percentage of the statements and branches of every file that were evaluated.
+func printCoverage(coverage *cover.Coverage, out io.Writer) {
+	wd, _ := os.Getwd()
+	for _, f := range coverage.Files() {
+		path := f.Path
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		fmt.Fprintf(out, "coverage: %5.1f%% of statements, %5.1f%% of branches in %s\n",
+			cover.Percent(f.StatementsCovered()), cover.Percent(f.BranchesCovered()), path)
+	}
+}
+
+func writeCoverage(coverage *cover.Coverage, filePath, format string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	if format == "html" {
+		err = coverage.WriteHTML(file)
+	} else {
+		err = coverage.WriteLcov(file)
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package main
+
+// isTerminal always tells that fd is not a terminal, so the repl reads lines without editing them.
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+// makeRaw never puts a terminal in raw mode on this system.
+func makeRaw(fd uintptr) (restore func(), ok bool) {
+	return nil, false
+}
@@ -0,0 +1,44 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// getTermios reads the settings of the terminal fd, it fails when fd is not a terminal.
+func getTermios(fd uintptr) (*syscall.Termios, bool) {
+	termios := &syscall.Termios{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(termios)))
+	return termios, errno == 0
+}
+
+func setTermios(fd uintptr, termios *syscall.Termios) bool {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(termios)))
+	return errno == 0
+}
+
+// isTerminal tells whether fd is a terminal.
+func isTerminal(fd uintptr) bool {
+	_, ok := getTermios(fd)
+	return ok
+}
+
+// makeRaw puts the terminal fd in raw mode, where the keys are read one at a time without being echoed, and returns
+// the function that puts it back in the mode it was in. ok is false when fd is not a terminal.
+func makeRaw(fd uintptr) (restore func(), ok bool) {
+	original, ok := getTermios(fd)
+	if !ok {
+		return nil, false
+	}
+
+	raw := *original
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cc[syscall.VMIN], raw.Cc[syscall.VTIME] = 1, 0
+	if !setTermios(fd, &raw) {
+		return nil, false
+	}
+	return func() { setTermios(fd, original) }, true
+}
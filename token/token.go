@@ -1,45 +1,69 @@
 package token
 
+import "sort"
+
 // Token is data-structure that represents tokens of the language.
 type Token struct {
 	Type    string
 	Literal string
+	Line    int // line of the token's first char in the source code, starting at 1
+	Column  int // column of the token's first char in its line, starting at 1
 }
 
 // List of all tokens in the language.
 var (
 	// Operators
-	ASSIGN   = Token{"ASSIGN", "="}
-	PLUS     = Token{"PLUS", "+"}
-	MINUS    = Token{"MINUS", "-"}
-	BANG     = Token{"BANG", "!"}
-	ASTERISK = Token{"ASTERISK", "*"}
-	SLASH    = Token{"SLASH", "/"}
+	ASSIGN   = Token{Type: "ASSIGN", Literal: "="}
+	ARROW    = Token{Type: "ARROW", Literal: "=>"}
+	PLUS     = Token{Type: "PLUS", Literal: "+"}
+	MINUS    = Token{Type: "MINUS", Literal: "-"}
+	BANG     = Token{Type: "BANG", Literal: "!"}
+	ASTERISK = Token{Type: "ASTERISK", Literal: "*"}
+	SLASH    = Token{Type: "SLASH", Literal: "/"}
 
-	LT = Token{"LT", "<"}
-	GT = Token{"GT", ">"}
+	LT = Token{Type: "LT", Literal: "<"}
+	GT = Token{Type: "GT", Literal: ">"}
 
-	EQ    = Token{"EQ", "=="}
-	NOTEQ = Token{"NOTEQ", "!="}
+	EQ    = Token{Type: "EQ", Literal: "=="}
+	NOTEQ = Token{Type: "NOTEQ", Literal: "!="}
 
 	// Delimiters
-	COMMA     = Token{"COMMA", ","}
-	SEMICOLON = Token{"SEMICOLAN", ";"}
+	COMMA     = Token{Type: "COMMA", Literal: ","}
+	SEMICOLON = Token{Type: "SEMICOLAN", Literal: ";"}
+	DOT       = Token{Type: "DOT", Literal: "."}
+	QUESTION  = Token{Type: "QUESTION", Literal: "?"}
+	COLON     = Token{Type: "COLON", Literal: ":"}
 
 	// Brackets
-	LPAREN = Token{"LPAREN", "("}
-	RPAREN = Token{"RPAREN", ")"}
-	LBRACE = Token{"LBRACE", "{"}
-	RBRACE = Token{"RBRACE", "}"}
+	LPAREN   = Token{Type: "LPAREN", Literal: "("}
+	RPAREN   = Token{Type: "RPAREN", Literal: ")"}
+	LBRACE   = Token{Type: "LBRACE", Literal: "{"}
+	RBRACE   = Token{Type: "RBRACE", Literal: "}"}
+	LBRACKET = Token{Type: "LBRACKET", Literal: "["}
+	RBRACKET = Token{Type: "RBRACKET", Literal: "]"}
 
 	// Keywords
-	FUNCTION = Token{"FUNCTION", "func"}
-	LET      = Token{"LET", "let"}
-	IF       = Token{"IF", "if"}
-	ELSE     = Token{"ELSE", "else"}
-	RETURN   = Token{"RETURN", "return"}
-	TRUE     = Token{"TRUE", "true"}
-	FALSE    = Token{"FALSE", "false"}
+	FUNCTION = Token{Type: "FUNCTION", Literal: "func"}
+	LET      = Token{Type: "LET", Literal: "let"}
+	CONST    = Token{Type: "CONST", Literal: "const"}
+	IF       = Token{Type: "IF", Literal: "if"}
+	ELSE     = Token{Type: "ELSE", Literal: "else"}
+	RETURN   = Token{Type: "RETURN", Literal: "return"}
+	TRUE     = Token{Type: "TRUE", Literal: "true"}
+	FALSE    = Token{Type: "FALSE", Literal: "false"}
+	IMPORT   = Token{Type: "IMPORT", Literal: "import"}
+	AS       = Token{Type: "AS", Literal: "as"}
+	EXPORT   = Token{Type: "EXPORT", Literal: "export"}
+	TRY      = Token{Type: "TRY", Literal: "try"}
+	CATCH    = Token{Type: "CATCH", Literal: "catch"}
+	FINALLY  = Token{Type: "FINALLY", Literal: "finally"}
+	THROW    = Token{Type: "THROW", Literal: "throw"}
+	SPAWN    = Token{Type: "SPAWN", Literal: "spawn"}
+	SELECT   = Token{Type: "SELECT", Literal: "select"}
+	CASE     = Token{Type: "CASE", Literal: "case"}
+	DEFAULT  = Token{Type: "DEFAULT", Literal: "default"}
+	STRUCT   = Token{Type: "STRUCT", Literal: "struct"}
+	MATCH    = Token{Type: "MATCH", Literal: "match"}
 
 	// Identifiers + Literals
 	IDENTIFIER = Token{Type: "IDENTIFIER"} // add, foobar, x, y, ...
@@ -47,19 +71,35 @@ var (
 	STRING     = Token{Type: "STRING"}
 
 	// Special tokens
-	ILLEGAL = Token{Type: "ILLEGAL"}
-	EOF     = Token{"EOF", ""}
+	ILLEGAL      = Token{Type: "ILLEGAL"}
+	UNTERMINATED = Token{Type: "UNTERMINATED"} // a string without its closing quote, which runs till the end of the input
+	COMMENT      = Token{Type: "COMMENT"}      // never returned by the lexer's NextToken, see Lexer.Comments
+	EOF          = Token{Type: "EOF", Literal: ""}
 )
 
 // keywords table maps all the keyword token literals to their token values
 var keywords = map[string]Token{
-	"func":   FUNCTION,
-	"let":    LET,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
-	"true":   TRUE,
-	"false":  FALSE,
+	"func":    FUNCTION,
+	"let":     LET,
+	"const":   CONST,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"true":    TRUE,
+	"false":   FALSE,
+	"import":  IMPORT,
+	"as":      AS,
+	"export":  EXPORT,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"throw":   THROW,
+	"spawn":   SPAWN,
+	"select":  SELECT,
+	"case":    CASE,
+	"default": DEFAULT,
+	"struct":  STRUCT,
+	"match":   MATCH,
 }
 
 // GetTokenForLetterStringLiteral returns token for a letter-string literal.
@@ -71,3 +111,13 @@ func GetTokenForLetterStringLiteral(literal string) Token {
 	identifierToken.Literal = literal
 	return identifierToken
 }
+
+// Keywords returns the sorted literals of all the keywords in the language.
+func Keywords() []string {
+	literals := make([]string, 0, len(keywords))
+	for literal := range keywords {
+		literals = append(literals, literal)
+	}
+	sort.Strings(literals)
+	return literals
+}
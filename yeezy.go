@@ -2,7 +2,6 @@ package main
 
 import (
 	"bufio"
-	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,17 +9,17 @@ import (
 	"os/user"
 	"path/filepath"
 
-	"github.com/shksa/yeezy/object"
-
 	"github.com/shksa/yeezy/evaluator"
-	"github.com/shksa/yeezy/parser"
-
-	"github.com/shksa/yeezy/lexer"
 )
 
-var (
-	fileNamePtr = flag.String("file", "", "name of file to interpret")
-)
+// searchPath is the list of directories to look up imported files in, set by the -path flag or $YEEZYPATH.
+var searchPath = os.Getenv("YEEZYPATH")
+
+// profilePath is the file `yeezy run -profile` writes the profile of the program to, in the profileFormat format.
+var profilePath, profileFormat string
+
+// stdin is where a program given as "-" and the readLine and readAll built-ins read from.
+var stdin io.Reader = os.Stdin
 
 // PROMPT is the prompt message for the repl.
 const PROMPT = ">> "
@@ -45,90 +44,111 @@ const PEPE = `
 `
 
 func start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	repl(&scannerLineReader{scanner: bufio.NewScanner(in), out: out}, newSession(out))
+}
+
+// repl reads the input line by line and evaluates it in the session, till the input ends or the user exits.
+func repl(reader lineReader, session *session) {
 	for {
-		fmt.Printf(PROMPT)
-		didScan := scanner.Scan()
-		if !didScan {
+		line, err := reader.Prompt(PROMPT)
+		if err == errPromptAborted {
+			continue
+		}
+		if err != nil {
 			return
 		}
 
-		line := scanner.Text()
 		if line == "exit" {
 			return
 		}
 
-		l := lexer.New(line)
-		p := parser.New(l)
-
-		program := p.ParseProgram()
-
-		if len(p.Errors) != 0 {
-			printParseErrors(p.Errors)
+		if isCommand(line) {
+			if quit := session.runCommand(line); quit {
+				return
+			}
 			continue
 		}
 
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
-			// io.WriteString(out, program.String())
-			// io.WriteString(out, "\n")
+		// Lines are read till the input is a complete statement, so that a function literal can span many lines.
+		for isIncompleteInput(line) {
+			nextLine, err := reader.Prompt(CONTPROMPT)
+			if err == errPromptAborted {
+				line = ""
+				break
+			}
+			if err != nil {
+				return
+			}
+			line += "\n" + nextLine
 		}
+
+		session.eval(line)
 	}
 }
 
-func runREPL() {
+func runREPL() int {
 	user, err := user.Current()
 	if err != nil {
 		fmt.Println(err)
-		return
+		return exitRuntimeError
 	}
 	fmt.Printf("Hello %s! This is the Yeezy programming language!\n", user.Username)
-	fmt.Printf("Feel free to type in commands\n")
-	start(os.Stdin, os.Stdout)
+	fmt.Printf("Feel free to type in commands, or :help for the list of repl commands\n")
+
+	session := newSession(os.Stdout)
+	reader := newLineReader(session, os.Stdin, os.Stdout, historyFilePath())
+	defer reader.Close()
+	repl(reader, session)
+	return exitOK
 }
 
-func runProgramFile(filePath string) {
-	fileExtention := filepath.Ext(filePath)
-	if fileExtention != ".yz" {
-		fmt.Printf("Invalid file extention: Not a Yeezy file. got=%q \n", fileExtention)
-		return
+// runProgramFile runs the program in the file, or the program read from stdin when filePath is "-".
+// scriptArgs are the arguments the program gets from the args built-in.
+func runProgramFile(filePath string, scriptArgs []string, out, errOut io.Writer) int {
+	if filePath == "-" {
+		fileContent, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			return exitUsageError
+		}
+		return runProgram(string(fileContent), "", scriptArgs, out, errOut)
 	}
 
 	fileContent, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		fmt.Println(err)
-		return
+		fmt.Fprintln(errOut, err)
+		return exitUsageError
 	}
 
-	env := object.NewEnvironment()
-	l := lexer.New(string(fileContent))
-	p := parser.New(l)
-	program := p.ParseProgram()
+	return runProgram(string(fileContent), filePath, scriptArgs, out, errOut)
+}
 
-	if len(p.Errors) != 0 {
-		printParseErrors(p.Errors)
-		return
-	}
+func main() {
+	os.Exit(runCLI(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	evaluated := evaluator.Eval(program, env)
-	fmt.Println(evaluated.Inspect())
+// newInterpreter returns an interpreter that looks up imported files in the directories of the -path flag,
+// and that has the built-ins which give programs access to their arguments, environment variables and stdin.
+func newInterpreter(scriptArgs []string) *evaluator.Interpreter {
+	return newInterpreterReading(scriptArgs, stdin)
 }
 
-func main() {
-	cmdArgs := os.Args
-	if len(cmdArgs) == 1 {
-		runREPL()
-	} else {
-		runProgramFile(cmdArgs[1])
+// newInterpreterReading is newInterpreter with programs reading in instead of stdin, for commands that use stdin for
+// something else.
+func newInterpreterReading(scriptArgs []string, in io.Reader) *evaluator.Interpreter {
+	interpreter := evaluator.New()
+	for name, builtInFunc := range hostBuiltins(scriptArgs, in) {
+		interpreter.SetBuiltin(name, builtInFunc)
+	}
+	if searchPath != "" {
+		interpreter.SearchPath = filepath.SplitList(searchPath)
 	}
+	return interpreter
 }
 
-func printParseErrors(errors []string) {
-	fmt.Println(PEPE, "whoops! PEPE died after seeing your shit code!\n", "parse errors:")
+func printParseErrors(out io.Writer, errors []string) {
+	fmt.Fprintln(out, PEPE, "whoops! PEPE died after seeing your shit code!\n", "parse errors:")
 	for _, errMsg := range errors {
-		fmt.Println("\t", errMsg)
+		fmt.Fprintln(out, "\t", errMsg)
 	}
 }